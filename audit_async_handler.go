@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// AuditAsyncResponse is returned by /audit/async, poll /audit/status with the job ID
+type AuditAsyncResponse struct {
+	JobID string `json:"job_id"`
+}
+
+// AuditStatusResponse is the state of an async audit, Result is set once it is done
+// or cancelled
+type AuditStatusResponse struct {
+	JobID          string       `json:"job_id"`
+	Status         JobStatus    `json:"status"`
+	PagesProcessed int          `json:"pages_processed"`
+	CreatedAt      time.Time    `json:"created_at"`
+	FinishedAt     *time.Time   `json:"finished_at,omitempty"`
+	Error          string       `json:"error,omitempty"`
+	Result         *AuditResult `json:"result,omitempty"`
+}
+
+// auditAsyncHandler starts an audit in the background and returns its job ID right away.
+// A retry with the same Idempotency-Key returns the job of the first request.
+func auditAsyncHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validAPIKey(r) {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	key, err := idempotencyKey(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req AuditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, created := jobStore.CreateIdempotent(req.Tenant, key)
+	status := http.StatusOK
+	if created {
+		status = http.StatusAccepted
+		// Log lines of the audit carry the job ID clients poll with
+		req.Tenant.CorrelationID = job.ID
+		jobStore.Go(func() {
+			// The audit outlives the request but stays in its trace
+			result, err := Audit(context.WithoutCancel(r.Context()), req, job.ID)
+			if err != nil {
+				req.Tenant.Logger().Error("audit failed", "error", err)
+			}
+			jobStore.Finish(job.ID, result, err)
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(AuditAsyncResponse{JobID: job.ID})
+}
+
+// auditStatusHandler returns the state of an async audit, with the result once it is done
+func auditStatusHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := requestedJob(w, r)
+	if !ok {
+		return
+	}
+
+	response := AuditStatusResponse{
+		JobID:          job.ID,
+		Status:         job.Status,
+		PagesProcessed: job.PagesProcessed,
+		CreatedAt:      job.CreatedAt,
+		Error:          job.Error,
+	}
+	if job.Status != JobRunning {
+		response.FinishedAt = &job.FinishedAt
+	}
+	result, err := job.Result()
+	if err != nil {
+		http.Error(w, "failed to read audit result: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response.Result = result
+
+	writeJSONReport(w, r, "audit-"+job.ID, response)
+}
+
+// auditCancelHandler stops a running async audit. It returns once cancellation is
+// signaled, the job turns "cancelled" with the pages audited so far when the audit ends.
+func auditCancelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validAPIKey(r) {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+
+	jobID := query.Get("job_id")
+	if jobID == "" {
+		http.Error(w, "job_id is required", http.StatusBadRequest)
+		return
+	}
+	status, ok := jobStore.Cancel(jobID)
+	if !ok {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if status != JobRunning {
+		http.Error(w, "audit is already "+string(status), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(AuditAsyncResponse{JobID: jobID})
+}
+
+// auditGraphHandler returns the link graph of a finished async audit, as JSON or with
+// ?format=dot in GraphViz DOT format
+func auditGraphHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "dot" {
+		http.Error(w, `format must be "json" or "dot"`, http.StatusBadRequest)
+		return
+	}
+
+	job, ok := requestedJob(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := job.Result()
+	if err != nil {
+		http.Error(w, "failed to read audit result: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result == nil {
+		http.Error(w, "audit is "+string(job.Status)+", the graph is only available once it is done", http.StatusConflict)
+		return
+	}
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.Header().Set("Content-Disposition", `attachment; filename="graph-`+job.ID+`.dot"`)
+		result.LinkGraph.WriteDOT(w)
+		return
+	}
+	writeJSONReport(w, r, "graph-"+job.ID, result.LinkGraph)
+}
+
+// auditSitemapHandler returns the pages of a finished async audit as a sitemap.xml
+func auditSitemapHandler(w http.ResponseWriter, r *http.Request) {
+	job, ok := requestedJob(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := job.Result()
+	if err != nil {
+		http.Error(w, "failed to read audit result: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result == nil {
+		http.Error(w, "audit is "+string(job.Status)+", the sitemap is only available once it is done", http.StatusConflict)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := generateSitemap(&buf, result, job.FinishedAt); err != nil {
+		http.Error(w, "failed to generate sitemap: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", `attachment; filename="sitemap.xml"`)
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
+// requestedJob checks a GET request for a job and looks up its ?job_id=, writing the
+// error response and returning false when that fails
+func requestedJob(w http.ResponseWriter, r *http.Request) (Job, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return Job{}, false
+	}
+
+	if !validAPIKey(r) {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return Job{}, false
+	}
+
+	query := r.URL.Query()
+
+	if err := validateReportCompress(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return Job{}, false
+	}
+
+	jobID := query.Get("job_id")
+	if jobID == "" {
+		http.Error(w, "job_id is required", http.StatusBadRequest)
+		return Job{}, false
+	}
+	job, ok := jobStore.Get(jobID)
+	if !ok {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return Job{}, false
+	}
+	return job, true
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"html"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Checks struct {
@@ -20,6 +27,153 @@ type Checks struct {
 	Images      bool `json:"images"`
 	Links       bool `json:"links"`
 	Security    bool `json:"security"`
+	// RedirectChains also reports links taking more than MaxRedirectHops redirects, needs Links
+	RedirectChains bool `json:"redirect_chains"`
+	// JSContent compares the page with a JavaScript-disabled load to find client-rendered content
+	JSContent bool `json:"js_content"`
+	// StructuredData checks breadcrumbs and schema.org markup
+	StructuredData bool `json:"structured_data"`
+	// ContentRatio warns about pages whose visible text is a tiny fraction of their HTML
+	ContentRatio bool `json:"content_ratio"`
+	// Canonical checks the canonical link tags and that the target returns 200 without redirecting
+	Canonical bool `json:"canonical"`
+	// Robots reads the robots meta tag, warns about noindex pages and doesn't
+	// follow the links of nofollow pages
+	Robots bool `json:"robots"`
+	// Mobile checks the viewport meta tag
+	Mobile bool `json:"mobile"`
+	// Social checks the Open Graph and Twitter Card tags used for link previews
+	Social bool `json:"social"`
+	// EmptyPage warns about pages whose text is below EmptyPageMinWords words or
+	// EmptyPageMinBytes bytes, e.g. maintenance pages and JS shells that return 200.
+	// ExcludeEmptyPages also leaves them out of the content ratio and duplicate checks.
+	EmptyPage         bool `json:"empty_page"`
+	EmptyPageMinWords int  `json:"empty_page_min_words"`
+	EmptyPageMinBytes int  `json:"empty_page_min_bytes"`
+	ExcludeEmptyPages bool `json:"exclude_empty_pages"`
+	// ThinContent warns about pages with fewer than ThinContentMinWords words, 0 uses the default
+	ThinContent         bool `json:"thin_content"`
+	ThinContentMinWords int  `json:"thin_content_min_words"`
+	// SoftNotFound warns about pages that return 200 but read like a "not found" page,
+	// see DefaultSoftNotFoundPhrases. SoftNotFoundPhrases adds phrases, e.g. for other languages.
+	SoftNotFound        bool     `json:"soft_not_found"`
+	SoftNotFoundPhrases []string `json:"soft_not_found_phrases"`
+	// Har records the page's network activity as a HAR on the result. Heavy, for debugging only.
+	Har bool `json:"har"`
+
+	// FastMeta only extracts title, meta description, H1s and links. Body text,
+	// keyword analysis and broken-link checks are skipped to crawl large sites quickly.
+	FastMeta bool `json:"fast_meta"`
+
+	// Title and description length bounds in characters, 0 uses the defaults below
+	TitleMinLen int `json:"title_min_len"`
+	TitleMaxLen int `json:"title_max_len"`
+	DescMinLen  int `json:"desc_min_len"`
+	DescMaxLen  int `json:"desc_max_len"`
+}
+
+// Default title and description length bounds
+const (
+	DefaultTitleMinLen = 30
+	DefaultTitleMaxLen = 65
+	DefaultDescMinLen  = 30
+	DefaultDescMaxLen  = 165
+)
+
+// Default empty page thresholds
+const (
+	DefaultEmptyPageMinWords = 20
+	DefaultEmptyPageMinBytes = 100
+)
+
+// DefaultThinContentMinWords is the word count below which a page is thin
+const DefaultThinContentMinWords = 200
+
+// orDefault returns value, or fallback when value is unset
+func orDefault(value int, fallback int) int {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
+
+// lengthBounds fills unset length bounds with the defaults. A default minimum above a
+// maximum the client set is lowered to it, so title_max_len 25 alone is valid.
+func lengthBounds(minLen int, maxLen int, defaultMin int, defaultMax int) (int, int) {
+	if minLen == 0 && maxLen != 0 {
+		return min(defaultMin, maxLen), maxLen
+	}
+	return orDefault(minLen, defaultMin), orDefault(maxLen, defaultMax)
+}
+
+// titleBounds returns the title length bounds to check against
+func (c Checks) titleBounds() (minLen int, maxLen int) {
+	return lengthBounds(c.TitleMinLen, c.TitleMaxLen, DefaultTitleMinLen, DefaultTitleMaxLen)
+}
+
+// descBounds returns the meta description length bounds to check against
+func (c Checks) descBounds() (minLen int, maxLen int) {
+	return lengthBounds(c.DescMinLen, c.DescMaxLen, DefaultDescMinLen, DefaultDescMaxLen)
+}
+
+// emptyPageBounds returns the thresholds below which a page counts as empty
+func (c Checks) emptyPageBounds() (minWords int, minBytes int) {
+	return orDefault(c.EmptyPageMinWords, DefaultEmptyPageMinWords), orDefault(c.EmptyPageMinBytes, DefaultEmptyPageMinBytes)
+}
+
+// Validate checks the length thresholds
+func (c Checks) Validate() error {
+	if c.TitleMinLen < 0 || c.TitleMaxLen < 0 || c.DescMinLen < 0 || c.DescMaxLen < 0 {
+		return errors.New("length thresholds must not be negative")
+	}
+	if c.EmptyPageMinWords < 0 || c.EmptyPageMinBytes < 0 {
+		return errors.New("empty page thresholds must not be negative")
+	}
+	if c.ThinContentMinWords < 0 {
+		return errors.New("thin_content_min_words must not be negative")
+	}
+	if minLen, maxLen := c.titleBounds(); minLen > maxLen {
+		return fmt.Errorf("title_min_len (%d) is greater than title_max_len (%d)", minLen, maxLen)
+	}
+	if minLen, maxLen := c.descBounds(); minLen > maxLen {
+		return fmt.Errorf("desc_min_len (%d) is greater than desc_max_len (%d)", minLen, maxLen)
+	}
+	return nil
+}
+
+// defaultChecks is used when a request doesn't specify which checks to run
+func defaultChecks() Checks {
+	return Checks{
+		Headings:    true,
+		Title:       true,
+		Description: true,
+		Keywords:    true,
+		Images:      false,
+		Links:       false,
+		Security:    true,
+	}
+}
+
+// checkHeadingOrder warns when a heading is more than one level deeper than the one
+// before it, e.g. an h4 right after an h2. Payload: page URL, then each skipped transition ("h2 > h4").
+func checkHeadingOrder(headingLevels []int, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	skipped := []string{}
+	for i := 1; i < len(headingLevels); i++ {
+		if headingLevels[i] > headingLevels[i-1]+1 {
+			transition := fmt.Sprintf("h%d > h%d", headingLevels[i-1], headingLevels[i])
+			if !slices.Contains(skipped, transition) {
+				skipped = append(skipped, transition)
+			}
+		}
+	}
+
+	if len(skipped) > 0 {
+		warnings[WarningHeadingSkipped] = append([]string{pageURL}, skipped...)
+	}
+
+	return warnings
 }
 
 // checkH1 validates H1 heading elements and returns any warnings
@@ -45,9 +199,20 @@ func checkH1(h1Texts []string, pageURL string) map[WarningType][]string {
 	return warnings
 }
 
+// cleanMetaText decodes HTML entities and trims whitespace so lengths match what users see
+func cleanMetaText(text string) string {
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
 // checkTitle validates the page title and returns any warnings
-func checkTitle(title string, pageURL string) map[WarningType][]string {
+func checkTitle(title string, titleCount int, minLen int, maxLen int, pageURL string) map[WarningType][]string {
 	warnings := make(map[WarningType][]string)
+	title = cleanMetaText(title)
+
+	// Check if the page has more than one <title>
+	if titleCount > 1 {
+		warnings[WarningTitleMultiple] = []string{pageURL, fmt.Sprintf("%d", titleCount)}
+	}
 
 	// Check if title is missing
 	if title == "" {
@@ -55,14 +220,17 @@ func checkTitle(title string, pageURL string) map[WarningType][]string {
 		return warnings
 	}
 
+	// Search engines truncate by characters, not bytes
+	titleLen := utf8.RuneCountInString(title)
+
 	// Check if title is too short
-	if len(title) < 30 {
+	if titleLen < minLen {
 		warnings[WarningTitleTooShort] = []string{pageURL, title}
 		return warnings
 	}
 
 	// Check if title is too long
-	if len(title) > 65 {
+	if titleLen > maxLen {
 		warnings[WarningTitleTooLong] = []string{pageURL, title}
 		return warnings
 	}
@@ -71,8 +239,14 @@ func checkTitle(title string, pageURL string) map[WarningType][]string {
 }
 
 // checkDescription validates the meta description and returns any warnings
-func checkDescription(metaDesc string, pageURL string) map[WarningType][]string {
+func checkDescription(metaDesc string, descCount int, minLen int, maxLen int, pageURL string) map[WarningType][]string {
 	warnings := make(map[WarningType][]string)
+	metaDesc = cleanMetaText(metaDesc)
+
+	// Check if the page has more than one meta description
+	if descCount > 1 {
+		warnings[WarningMetaDescriptionMultiple] = []string{pageURL, fmt.Sprintf("%d", descCount)}
+	}
 
 	// Check if description is missing
 	if metaDesc == "" {
@@ -80,14 +254,17 @@ func checkDescription(metaDesc string, pageURL string) map[WarningType][]string
 		return warnings
 	}
 
+	// Search engines truncate by characters, not bytes
+	descLen := utf8.RuneCountInString(metaDesc)
+
 	// Check if description is too short
-	if len(metaDesc) < 30 {
+	if descLen < minLen {
 		warnings[WarningMetaDescriptionTooShort] = []string{pageURL, metaDesc}
 		return warnings
 	}
 
 	// Check if description is too long
-	if len(metaDesc) > 165 {
+	if descLen > maxLen {
 		warnings[WarningMetaDescriptionTooLong] = []string{pageURL, metaDesc}
 		return warnings
 	}
@@ -95,6 +272,194 @@ func checkDescription(metaDesc string, pageURL string) map[WarningType][]string
 	return warnings
 }
 
+// checkJSContent warns when a meaningful part of the text or links only exist once JavaScript runs.
+// Payload: page URL, words without JS, words with JS, number of links only present with JS.
+func checkJSContent(jsWords int, noJSWords int, jsLinks []string, noJSLinks []string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	noJSLinkSet := make(map[string]bool)
+	for _, link := range noJSLinks {
+		noJSLinkSet[link] = true
+	}
+	jsOnlyLinks := make(map[string]bool)
+	for _, link := range jsLinks {
+		if !noJSLinkSet[link] {
+			jsOnlyLinks[link] = true
+		}
+	}
+
+	// At least half the text, or a handful of links, missing without JavaScript
+	missingText := jsWords-noJSWords >= 50 && noJSWords*2 <= jsWords
+	missingLinks := len(jsOnlyLinks) >= 5
+
+	if missingText || missingLinks {
+		warnings[WarningContentRequiresJS] = []string{
+			pageURL,
+			fmt.Sprintf("%d", noJSWords),
+			fmt.Sprintf("%d", jsWords),
+			fmt.Sprintf("%d", len(jsOnlyLinks)),
+		}
+	}
+
+	return warnings
+}
+
+// MinTextRatio is the visible text to HTML length ratio below which a page is mostly markup
+const MinTextRatio = 0.1
+
+// isEmptyPage reports whether the page text is below either threshold
+func isEmptyPage(pageText string, minWords int, minBytes int) bool {
+	text := strings.TrimSpace(pageText)
+	return countWords(text) < minWords || len(text) < minBytes
+}
+
+// checkEmptyPage warns when the page has next to no text. Payload: page URL, word count, byte count.
+func checkEmptyPage(pageText string, minWords int, minBytes int, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if isEmptyPage(pageText, minWords, minBytes) {
+		text := strings.TrimSpace(pageText)
+		warnings[WarningEmptyPage] = []string{pageURL, fmt.Sprintf("%d", countWords(text)), fmt.Sprintf("%d", len(text))}
+	}
+
+	return warnings
+}
+
+// countWords counts the words of text. Chinese and Japanese aren't written with spaces
+// between words, so each Han, Hiragana and Katakana character counts as a word, the
+// usual way of measuring their length. Other words are runs of letters and digits,
+// joined by apostrophes and hyphens.
+func countWords(text string) int {
+	words := 0
+	for _, field := range strings.Fields(text) {
+		inWord := false
+		for _, r := range field {
+			switch {
+			case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+				words++
+				inWord = false
+			case unicode.IsLetter(r) || unicode.IsNumber(r):
+				if !inWord {
+					words++
+					inWord = true
+				}
+			case r == '\'' || r == '’' || r == '-':
+				// Inside words like "we're" and "wood-fired"
+			default:
+				inWord = false
+			}
+		}
+	}
+	return words
+}
+
+// checkWordCount warns when the page has fewer than minWords words, see countWords.
+// Payload: page URL, word count.
+func checkWordCount(pageText string, minWords int, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if words := countWords(pageText); words < minWords {
+		warnings[WarningThinContent] = []string{pageURL, fmt.Sprintf("%d", words)}
+	}
+
+	return warnings
+}
+
+// checkStatusCode warns when the page itself answered with a 4xx or 5xx status.
+// Payload: page URL, status code.
+func checkStatusCode(statusCode int, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if statusCode >= 400 {
+		warnings[WarningPageNotOK] = []string{pageURL, strconv.Itoa(statusCode)}
+	}
+
+	return warnings
+}
+
+// checkTextRatio warns when the visible text is a small fraction of the HTML.
+// Payload: page URL, ratio with two decimals.
+func checkTextRatio(textRatio float64, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if textRatio < MinTextRatio {
+		warnings[WarningLowContentRatio] = []string{pageURL, fmt.Sprintf("%.2f", textRatio)}
+	}
+
+	return warnings
+}
+
+// parseRobotsMeta returns the lowercased directives of a robots meta tag content
+func parseRobotsMeta(content string) []string {
+	directives := []string{}
+	for _, directive := range strings.Split(content, ",") {
+		if directive = strings.ToLower(strings.TrimSpace(directive)); directive != "" {
+			directives = append(directives, directive)
+		}
+	}
+	return directives
+}
+
+// checkRobotsMeta warns when the page asks not to be indexed. Payload: page URL, robots meta content.
+func checkRobotsMeta(robotsMeta string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	directives := parseRobotsMeta(robotsMeta)
+	if slices.Contains(directives, "noindex") || slices.Contains(directives, "none") {
+		warnings[WarningRobotsNoindex] = []string{pageURL, robotsMeta}
+	}
+
+	return warnings
+}
+
+// checkViewport warns when the viewport meta tag is missing, or doesn't set width=device-width.
+// viewport is nil when there is no tag. Payload: page URL, then the tag content when invalid.
+func checkViewport(viewport *string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if viewport == nil {
+		warnings[WarningViewportMissing] = []string{pageURL}
+		return warnings
+	}
+
+	content := strings.ToLower(strings.ReplaceAll(*viewport, " ", ""))
+	if !slices.Contains(strings.FieldsFunc(content, func(r rune) bool { return r == ',' || r == ';' }), "width=device-width") {
+		warnings[WarningViewportInvalid] = []string{pageURL, *viewport}
+	}
+
+	return warnings
+}
+
+// checkImageAltText flags images without an alt attribute or with an empty one
+func checkImageAltText(imgAttrs []map[string]string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	missingAlt := []string{}
+	for _, attrs := range imgAttrs {
+		if alt, ok := attrs["alt"]; !ok || strings.TrimSpace(alt) == "" {
+			missingAlt = append(missingAlt, attrs["src"])
+		}
+	}
+
+	if len(missingAlt) > 0 {
+		warnings[WarningImageAltMissing] = append([]string{pageURL}, missingAlt...)
+	}
+
+	return warnings
+}
+
+// checkBreadcrumbs warns when a page below the top level of the site has no breadcrumb markup
+func checkBreadcrumbs(hasBreadcrumbs bool, depth int, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	// The homepage and its direct children don't need breadcrumbs
+	if depth > 1 && !hasBreadcrumbs {
+		warnings[WarningBreadcrumbMissing] = []string{pageURL, fmt.Sprintf("%d", depth)}
+	}
+
+	return warnings
+}
+
 // checkLinks validates links on the page and returns any warnings
 func checkLinkProtocol(linkHrefs []string, pageURL string) map[WarningType][]string {
 	warnings := make(map[WarningType][]string)
@@ -121,10 +486,102 @@ func checkLinkProtocol(linkHrefs []string, pageURL string) map[WarningType][]str
 	return warnings
 }
 
-var compiled = make(map[string]*regexp.Regexp)
+// checkMixedContent warns when an HTTPS page loads resources over plain HTTP, browsers
+// block or upgrade them and flag the page as not secure. Payload: page URL, then the resource URLs.
+func checkMixedContent(resourceURLs []string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	parsed, err := url.Parse(pageURL)
+	if err != nil || parsed.Scheme != "https" {
+		return warnings
+	}
+
+	insecure := []string{}
+	for _, resourceURL := range resourceURLs {
+		parsedResource, err := url.Parse(resourceURL)
+		if err != nil {
+			continue
+		}
+		if parsedResource.Scheme == "http" && !slices.Contains(insecure, resourceURL) {
+			insecure = append(insecure, resourceURL)
+		}
+	}
+
+	if len(insecure) > 0 {
+		warnings[WarningMixedContent] = append([]string{pageURL}, insecure...)
+	}
+
+	return warnings
+}
+
+// tlsHostCache maps host:port to the certificate problem, "" when it's valid. Entries
+// expire after LINK_CACHE_TTL so renewed or expired certificates are noticed.
+var tlsHostCache = newTTLCache[string](linkCacheTTL())
+
+// checkSSL warns when a page isn't served over HTTPS or its certificate is invalid or expired
+func checkSSL(pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return warnings
+	}
+
+	if parsed.Scheme == "http" {
+		warnings[WarningSSLNo] = []string{pageURL, "page is not served over https"}
+		return warnings
+	}
+	if parsed.Scheme != "https" {
+		return warnings
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = "443"
+	}
+	hostPort := net.JoinHostPort(parsed.Hostname(), port)
+
+	problem, checked := tlsHostCache.Get(hostPort)
+	if !checked {
+		problem = tlsProblem(parsed.Hostname(), hostPort)
+		tlsHostCache.Set(hostPort, problem)
+	}
+
+	if problem != "" {
+		warnings[WarningSSLNo] = []string{pageURL, problem}
+	}
+
+	return warnings
+}
+
+// tlsProblem does a TLS handshake with full verification and describes what's wrong, if anything.
+// This always verifies, even for audits with InsecureSkipVerify, so the warning stays accurate.
+func tlsProblem(serverName string, hostPort string) string {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", hostPort, &tls.Config{ServerName: serverName})
+	if err != nil {
+		return err.Error()
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) > 0 && time.Now().After(certs[0].NotAfter) {
+		return "certificate expired on " + certs[0].NotAfter.Format(time.DateOnly)
+	}
+
+	return ""
+}
+
+var (
+	compiled   = make(map[string]*regexp.Regexp)
+	compiledMu sync.RWMutex
+)
 
 func getRegex(keyword string) (*regexp.Regexp, error) {
-	if re, ok := compiled[keyword]; ok {
+	compiledMu.RLock()
+	re, ok := compiled[keyword]
+	compiledMu.RUnlock()
+	if ok {
 		return re, nil
 	}
 
@@ -134,40 +591,61 @@ func getRegex(keyword string) (*regexp.Regexp, error) {
 		return nil, err
 	}
 
+	compiledMu.Lock()
 	compiled[keyword] = re
+	compiledMu.Unlock()
 	return re, nil
 }
 
-var (
-	linkMap   = make(map[string]bool)
-	linkMapMu sync.RWMutex
-)
+// LinkCheckOptions configure how checkBrokenLinks verifies links
+type LinkCheckOptions struct {
+	Cache              *LinkCache // defaults to globalLinkCache
+	InsecureSkipVerify bool
+	Referer            string // sent with every link check, see validateReferer
+	// RecordRedirects warns about links that take more than MaxRedirectHops redirects to resolve
+	RecordRedirects bool
+}
+
+// MaxRedirectHops is the most redirects a link may take before it gets WarningRedirectChain
+const MaxRedirectHops = 2
+
+// linkCheck is the verdict for a link. redirects is the chain from the link to where
+// it ended up, nil when it didn't redirect.
+type linkCheck struct {
+	alive     bool
+	redirects []string
+}
+
+// linkResult is a checked link as reported by linkWorker
+type linkResult struct {
+	link  string
+	check linkCheck
+}
 
 func linkWorker(
 	jobs <-chan string,
-	results chan<- string,
+	results chan<- linkResult,
+	o LinkCheckOptions,
 ) {
 	for link := range jobs {
-		linkMapMu.RLock()
-		works, existsInMap := linkMap[link]
-		linkMapMu.RUnlock()
+		check, existsInCache := o.Cache.Get(link)
 
-		if !existsInMap {
-			works = isLinkAlive(link)
-
-			linkMapMu.Lock()
-			linkMap[link] = works
-			linkMapMu.Unlock()
+		if !existsInCache {
+			check = checkLink(link, o.InsecureSkipVerify, o.Referer)
+			o.Cache.Set(link, check)
 		}
 
-		if !works {
-			results <- link
+		if !check.alive || len(check.redirects) > 0 {
+			results <- linkResult{link: link, check: check}
 		}
 	}
 }
 
-func checkBrokenLinks(pageURL string, links []string, checked map[string]bool) map[WarningType][]string {
+func checkBrokenLinks(pageURL string, links []string, checked map[string]bool, o LinkCheckOptions) map[WarningType][]string {
 	warnings := make(map[WarningType][]string)
+	if o.Cache == nil {
+		o.Cache = globalLinkCache
+	}
 
 	mainUrl, err := url.Parse(pageURL)
 	if err != nil {
@@ -175,14 +653,14 @@ func checkBrokenLinks(pageURL string, links []string, checked map[string]bool) m
 	}
 
 	jobs := make(chan string)
-	results := make(chan string)
+	results := make(chan linkResult)
 
 	var wg sync.WaitGroup
 
 	// Spawn 5 workers
 	for range 5 {
 		wg.Go(func() {
-			linkWorker(jobs, results)
+			linkWorker(jobs, results, o)
 		})
 	}
 
@@ -213,41 +691,140 @@ func checkBrokenLinks(pageURL string, links []string, checked map[string]bool) m
 	}()
 
 	// Collect results
-	for brokenLink := range results {
-		if len(warnings[WarningLinksBroken]) == 0 {
-			warnings[WarningLinksBroken] = []string{pageURL}
+	for result := range results {
+		if !result.check.alive {
+			if len(warnings[WarningLinksBroken]) == 0 {
+				warnings[WarningLinksBroken] = []string{pageURL}
+			}
+			warnings[WarningLinksBroken] = append(warnings[WarningLinksBroken], result.link)
+		}
+
+		// Payload: page URL, then each long chain as "link -> hop -> ... -> final URL"
+		if o.RecordRedirects && len(result.check.redirects)-1 > MaxRedirectHops {
+			if len(warnings[WarningRedirectChain]) == 0 {
+				warnings[WarningRedirectChain] = []string{pageURL}
+			}
+			warnings[WarningRedirectChain] = append(warnings[WarningRedirectChain], strings.Join(result.check.redirects, " -> "))
 		}
-		warnings[WarningLinksBroken] = append(warnings[WarningLinksBroken], brokenLink)
 	}
 
 	return warnings
 }
 
-func isLinkAlive(url string) bool {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 10 {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
+// checkBrokenAnchors warns about in-page links whose fragment matches no element.
+// Payload: page URL, then the distinct fragments ("#section-2").
+func checkBrokenAnchors(missingAnchors []string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	broken := []string{}
+	for _, anchor := range missingAnchors {
+		if !slices.Contains(broken, anchor) {
+			broken = append(broken, anchor)
+		}
 	}
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	req.Header.Set("Range", "bytes=0-0")
+	if len(broken) > 0 {
+		warnings[WarningBrokenAnchor] = append([]string{pageURL}, broken...)
+	}
+
+	return warnings
+}
+
+// capRedirects stops following redirects after 10 hops
+func capRedirects(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
+
+// linkClient is shared by all link checks
+var linkClient = &http.Client{
+	Timeout:       5 * time.Second,
+	CheckRedirect: capRedirects,
+}
+
+// insecureLinkClient skips certificate verification, see insecure_hosts.go
+var insecureLinkClient = &http.Client{
+	Timeout:       5 * time.Second,
+	CheckRedirect: capRedirects,
+	Transport: func() *http.Transport {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		return t
+	}(),
+}
+
+// linkClientFor picks the client for a link, only skipping verification for private/allowlisted hosts
+func linkClientFor(link string, insecureSkipVerify bool) *http.Client {
+	if !insecureSkipVerify {
+		return linkClient
+	}
+	parsed, err := url.Parse(link)
+	if err != nil || !isInsecureAllowedHost(parsed.Hostname()) {
+		return linkClient
+	}
+	return insecureLinkClient
+}
+
+// checkLink sends a HEAD request, falling back to a ranged GET for servers that don't support HEAD
+func checkLink(url string, insecureSkipVerify bool, referer string) linkCheck {
+	client := linkClientFor(url, insecureSkipVerify)
+
+	status, redirects, err := linkStatus(client, http.MethodHead, url, referer)
 	if err != nil {
-		return false
+		return linkCheck{}
+	}
+	if status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+		status, redirects, err = linkStatus(client, http.MethodGet, url, referer)
+		if err != nil {
+			return linkCheck{}
+		}
+	}
+
+	// Consider 2xx and 3xx as "alive"
+	return linkCheck{alive: status >= 200 && status < 400, redirects: redirects}
+}
+
+// linkStatus requests url with the given method and returns the response status code
+// and the redirects followed. GET requests only ask for the first byte.
+func linkStatus(client *http.Client, method string, url string, referer string) (int, []string, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return false
+		return 0, nil, err
 	}
-	defer resp.Body.Close()
+	resp.Body.Close()
 
-	// Consider 2xx and 3xx as "alive"
-	return resp.StatusCode >= 200 && resp.StatusCode < 400
+	return resp.StatusCode, redirectChain(resp), nil
+}
+
+// redirectChain returns the URLs the client went through to get resp, starting with
+// the requested one. nil when there were no redirects.
+func redirectChain(resp *http.Response) []string {
+	if resp.Request.Response == nil {
+		return nil
+	}
+
+	chain := []string{}
+	for req := resp.Request; req != nil; {
+		chain = append([]string{req.URL.String()}, chain...)
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+	return chain
 }
 
 func checkKeywords(content string, keywords []string, keywordMap map[string]int) {
@@ -268,3 +845,72 @@ func checkKeywords(content string, keywords []string, keywordMap map[string]int)
 		}
 	}
 }
+
+// PrimaryKeywordIntroWords is how far into the body text the primary keyword must appear
+const PrimaryKeywordIntroWords = 100
+
+// containsPhrase reports whether the words of phrase appear together in text, ignoring case and spacing
+func containsPhrase(text string, phrase string) bool {
+	re, err := getRegex(strings.Join(strings.Fields(phrase), " "))
+	if err != nil {
+		return false
+	}
+	return re.MatchString(strings.Join(strings.Fields(text), " "))
+}
+
+// checkPrimaryKeyword warns about each place the primary keyword should be but isn't:
+// title, an H1, the URL path, the meta description and the first PrimaryKeywordIntroWords
+// words of the body. The intro is skipped when pageText is empty (fast meta mode).
+// Payload: page URL, primary keyword.
+func checkPrimaryKeyword(primaryKeyword string, title string, h1Texts []string, metaDesc string, pageText string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+	payload := []string{pageURL, primaryKeyword}
+
+	if !containsPhrase(cleanMetaText(title), primaryKeyword) {
+		warnings[WarningPrimaryKeywordNotInTitle] = payload
+	}
+	if !containsPhrase(strings.Join(h1Texts, " | "), primaryKeyword) {
+		warnings[WarningPrimaryKeywordNotInH1] = payload
+	}
+	if !containsPhrase(cleanMetaText(metaDesc), primaryKeyword) {
+		warnings[WarningPrimaryKeywordNotInDescription] = payload
+	}
+
+	// Slugs separate words with dashes, underscores or slashes
+	if parsed, err := url.Parse(pageURL); err == nil {
+		slug := strings.NewReplacer("-", " ", "_", " ", "/", " ", ".", " ", "+", " ").Replace(parsed.Path)
+		if !containsPhrase(slug, primaryKeyword) {
+			warnings[WarningPrimaryKeywordNotInURL] = payload
+		}
+	}
+
+	if pageText != "" {
+		intro := strings.Fields(pageText)
+		if len(intro) > PrimaryKeywordIntroWords {
+			intro = intro[:PrimaryKeywordIntroWords]
+		}
+		if !containsPhrase(strings.Join(intro, " "), primaryKeyword) {
+			warnings[WarningPrimaryKeywordNotInIntro] = payload
+		}
+	}
+
+	return warnings
+}
+
+// checkKeywordsMissing lists the keyword phrases that didn't match anywhere on the page
+func checkKeywordsMissing(keywords []string, keywordMap map[string]int, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	missing := []string{}
+	for _, keywordPhrase := range keywords {
+		if keywordMap[keywordPhrase] == 0 && !slices.Contains(missing, keywordPhrase) {
+			missing = append(missing, keywordPhrase)
+		}
+	}
+
+	if len(missing) > 0 {
+		warnings[WarningKeywordsMissing] = append([]string{pageURL}, missing...)
+	}
+
+	return warnings
+}
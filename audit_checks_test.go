@@ -0,0 +1,234 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testPageURL = "https://example.com/"
+
+func TestCheckTitleMultibyteBoundaries(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  WarningType
+	}{
+		{"min length in accented runes", strings.Repeat("é", DefaultTitleMinLen), ""},
+		{"below min length", strings.Repeat("é", DefaultTitleMinLen-1), WarningTitleTooShort},
+		{"max length in CJK runes", strings.Repeat("日", DefaultTitleMaxLen), ""},
+		{"above max length", strings.Repeat("日", DefaultTitleMaxLen+1), WarningTitleTooLong},
+		{"max length in emoji", strings.Repeat("🍕", DefaultTitleMaxLen), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := checkTitle(tt.title, 1, DefaultTitleMinLen, DefaultTitleMaxLen, testPageURL)
+			assertOnlyWarning(t, warnings, tt.want)
+		})
+	}
+}
+
+func TestCheckDescriptionMultibyteBoundaries(t *testing.T) {
+	tests := []struct {
+		name string
+		desc string
+		want WarningType
+	}{
+		{"min length in accented runes", strings.Repeat("ü", DefaultDescMinLen), ""},
+		{"below min length", strings.Repeat("ü", DefaultDescMinLen-1), WarningMetaDescriptionTooShort},
+		{"max length in CJK runes", strings.Repeat("語", DefaultDescMaxLen), ""},
+		{"above max length", strings.Repeat("語", DefaultDescMaxLen+1), WarningMetaDescriptionTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := checkDescription(tt.desc, 1, DefaultDescMinLen, DefaultDescMaxLen, testPageURL)
+			assertOnlyWarning(t, warnings, tt.want)
+		})
+	}
+}
+
+func TestCheckTitleCleansText(t *testing.T) {
+	tests := []struct {
+		name   string
+		title  string
+		minLen int
+		maxLen int
+		want   WarningType
+	}{
+		{"whitespace only is missing", "  \t\n ", DefaultTitleMinLen, DefaultTitleMaxLen, WarningTitleMissing},
+		// "Café Menu & Specials" is 20 characters, 25 bytes and 29 before decoding &amp;
+		{"entities decoded before measuring", "Café Menu &amp; Specials", 20, 20, ""},
+		{"multibyte counted in runes", "Café Menu & Specials", 20, 20, ""},
+		{"surrounding whitespace trimmed", "   Café Menu & Specials \n", 20, 20, ""},
+		{"one rune too long", "Café Menu & Specials!", 20, 20, WarningTitleTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := checkTitle(tt.title, 1, tt.minLen, tt.maxLen, testPageURL)
+			assertOnlyWarning(t, warnings, tt.want)
+		})
+	}
+}
+
+func TestCheckDescriptionCleansText(t *testing.T) {
+	if warnings := checkDescription(" \u00a0 ", 1, DefaultDescMinLen, DefaultDescMaxLen, testPageURL); len(warnings[WarningMetaDescriptionMissing]) == 0 {
+		t.Errorf("whitespace-only description: got %v, want %s", warnings, WarningMetaDescriptionMissing)
+	}
+
+	// 43 characters once decoded and trimmed
+	desc := "  Crème brûlée &amp; café au lait, served all day  "
+	warnings := checkDescription(desc, 1, 43, 43, testPageURL)
+	assertOnlyWarning(t, warnings, "")
+}
+
+func TestCheckH1(t *testing.T) {
+	tests := []struct {
+		name    string
+		h1Texts []string
+		want    []WarningType
+	}{
+		// A preallocated slice left empty entries next to the page's one H1
+		{"single H1", []string{"Welcome"}, nil},
+		{"no H1", nil, []WarningType{WarningH1Missing}},
+		{"empty H1", []string{""}, []WarningType{WarningH1Missing}},
+		{"two H1s", []string{"Welcome", "Offers"}, []WarningType{WarningH1Multiple}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := checkH1(tt.h1Texts, testPageURL)
+			if len(warnings) != len(tt.want) {
+				t.Fatalf("got warnings %v, want %v", warnings, tt.want)
+			}
+			for _, warningType := range tt.want {
+				if _, ok := warnings[warningType]; !ok {
+					t.Errorf("got warnings %v, want %s", warnings, warningType)
+				}
+			}
+		})
+	}
+}
+
+// The counts are what AuditPage reads from a page like
+// <head><title>Pizza Napoletana in Berlin | Da Mario</title><title>Da Mario</title></head>
+func TestMultipleTitlesAndDescriptions(t *testing.T) {
+	title := "Pizza Napoletana in Berlin | Da Mario"
+	desc := "Wood-fired Neapolitan pizza in the heart of Berlin, open every day."
+
+	warnings := checkTitle(title, 2, DefaultTitleMinLen, DefaultTitleMaxLen, testPageURL)
+	assertOnlyWarning(t, warnings, WarningTitleMultiple)
+	if got := warnings[WarningTitleMultiple]; len(got) != 2 || got[1] != "2" {
+		t.Errorf("got payload %v, want the URL and the count", got)
+	}
+
+	warnings = checkDescription(desc, 2, DefaultDescMinLen, DefaultDescMaxLen, testPageURL)
+	assertOnlyWarning(t, warnings, WarningMetaDescriptionMultiple)
+
+	// A single tag of each is fine
+	assertOnlyWarning(t, checkTitle(title, 1, DefaultTitleMinLen, DefaultTitleMaxLen, testPageURL), "")
+	assertOnlyWarning(t, checkDescription(desc, 1, DefaultDescMinLen, DefaultDescMaxLen, testPageURL), "")
+}
+
+// TestGetRegexConcurrent compiles and reads the same keywords from many goroutines,
+// like workers running checkKeywords at once. Run with -race.
+func TestGetRegexConcurrent(t *testing.T) {
+	keywords := []string{"seo", "pizza", "seo-audit", "new york"}
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			keyword := keywords[i%len(keywords)]
+			re, err := getRegex(keyword)
+			if err != nil {
+				t.Errorf("getRegex(%q): %v", keyword, err)
+				return
+			}
+			if !re.MatchString("best " + strings.ToUpper(keyword) + " in town") {
+				t.Errorf("getRegex(%q) doesn't match the keyword", keyword)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// assertOnlyWarning fails unless warnings holds want and nothing else, or nothing if want is empty
+func assertOnlyWarning(t *testing.T, warnings map[WarningType][]string, want WarningType) {
+	t.Helper()
+	if want == "" {
+		if len(warnings) != 0 {
+			t.Errorf("got warnings %v, want none", warnings)
+		}
+		return
+	}
+	if _, ok := warnings[want]; !ok || len(warnings) != 1 {
+		t.Errorf("got warnings %v, want only %s", warnings, want)
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Wood-fired pizza, open every day.", 5},
+		{"We're open 7 days a week", 6},
+		{"  Pizza  —  pasta \n", 2},
+		{"日本語のページです", 9},
+		{"東京のピザ店 Da Mario、2024年オープン", 14},
+		{"欢迎光临。", 4},
+		{"서울 최고의 피자", 3},
+	}
+
+	for _, tt := range tests {
+		if got := countWords(tt.text); got != tt.want {
+			t.Errorf("countWords(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWordCountCJK(t *testing.T) {
+	// A full article in Japanese is a handful of space separated fields
+	article := strings.Repeat("ナポリ風のピザを毎日薪窯で焼いています。", 15)
+	assertOnlyWarning(t, checkWordCount(article, DefaultThinContentMinWords, testPageURL), "")
+
+	assertOnlyWarning(t, checkWordCount("ページが見つかりません", DefaultThinContentMinWords, testPageURL), WarningThinContent)
+}
+
+func TestChecksLengthBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		checks  Checks
+		wantMin int
+		wantMax int
+		wantErr bool
+	}{
+		{"defaults", Checks{}, DefaultTitleMinLen, DefaultTitleMaxLen, false},
+		{"only max above default min", Checks{TitleMaxLen: 50}, DefaultTitleMinLen, 50, false},
+		{"only max below default min", Checks{TitleMaxLen: 25}, 25, 25, false},
+		{"only min", Checks{TitleMinLen: 10}, 10, DefaultTitleMaxLen, false},
+		{"both set", Checks{TitleMinLen: 10, TitleMaxLen: 25}, 10, 25, false},
+		{"min above set max", Checks{TitleMinLen: 40, TitleMaxLen: 25}, 40, 25, true},
+		{"min above default max", Checks{TitleMinLen: 70}, 70, DefaultTitleMaxLen, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minLen, maxLen := tt.checks.titleBounds()
+			if minLen != tt.wantMin || maxLen != tt.wantMax {
+				t.Errorf("titleBounds() = %d, %d, want %d, %d", minLen, maxLen, tt.wantMin, tt.wantMax)
+			}
+			if err := tt.checks.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Descriptions use the same rules
+	if minLen, maxLen := (Checks{DescMaxLen: 20}).descBounds(); minLen != 20 || maxLen != 20 {
+		t.Errorf("descBounds() with desc_max_len 20 = %d, %d, want 20, 20", minLen, maxLen)
+	}
+}
@@ -4,12 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WarningType represents the type of SEO/accessibility warning
@@ -19,6 +25,7 @@ const (
 	WarningH1Missing               WarningType = "h1_missing"
 	WarningH1Multiple              WarningType = "h1_multiple"
 	WarningH1Duplicate             WarningType = "h1_duplicate"
+	WarningHeadingSkipped          WarningType = "heading_skipped"
 	WarningTitleMissing            WarningType = "title_missing"
 	WarningTitleMultiple           WarningType = "title_multiple"
 	WarningTitleDuplicate          WarningType = "title_duplicate"
@@ -30,19 +37,85 @@ const (
 	WarningMetaDescriptionTooLong  WarningType = "meta_description_too_long"
 	WarningImageSizeTooBig         WarningType = "image_size_too_big"
 	WarningImageURLBroken          WarningType = "image_url_broken"
+	WarningImageAltMissing         WarningType = "image_alt_missing"
 	WarningLinksBroken             WarningType = "links_broken"
+	WarningBrokenAnchor            WarningType = "broken_anchor"
+	WarningRedirectChain           WarningType = "redirect_chain"
 	WarningSSLNo                   WarningType = "ssl_no"
 	WarningHTTPSToHTTPLinks        WarningType = "https_to_http_links"
+	WarningMixedContent            WarningType = "mixed_content"
 	WarningTimeoutPageLoad         WarningType = "timeout_page_load"
+	WarningPageNotOK               WarningType = "page_not_ok"
 	WarningKeywordsMissing         WarningType = "keywords_missing"
+	WarningBreadcrumbMissing       WarningType = "breadcrumb_missing"
+	WarningContentRequiresJS       WarningType = "content_requires_js"
+	WarningNotInSitemap            WarningType = "not_in_sitemap"
+	WarningSitemapUnreachable      WarningType = "sitemap_unreachable"
+	WarningLowContentRatio         WarningType = "low_content_ratio"
+	WarningStartURLRedirects       WarningType = "start_url_redirects"
+	WarningRobotsNoindex           WarningType = "robots_noindex"
+	WarningViewportMissing         WarningType = "viewport_missing"
+	WarningViewportInvalid         WarningType = "viewport_invalid"
+	WarningCanonicalMissing        WarningType = "canonical_missing"
+	WarningCanonicalMultiple       WarningType = "canonical_multiple"
+	WarningCanonicalExternal       WarningType = "canonical_external"
+	WarningCanonicalRedirects      WarningType = "canonical_redirects"
+	WarningCanonicalBroken         WarningType = "canonical_broken"
+	WarningStructuredDataMissing   WarningType = "structured_data_missing"
+	WarningStructuredDataInvalid   WarningType = "structured_data_invalid"
+	WarningEmptyPage               WarningType = "empty_page"
+	WarningThinContent             WarningType = "thin_content"
+	WarningSoftNotFound            WarningType = "soft_not_found"
+	WarningOpenGraphIncomplete     WarningType = "open_graph_incomplete"
+	WarningTwitterCardMissing      WarningType = "twitter_card_missing"
+	WarningTwitterCardIncomplete   WarningType = "twitter_card_incomplete"
+
+	WarningPrimaryKeywordNotInTitle       WarningType = "primary_keyword_not_in_title"
+	WarningPrimaryKeywordNotInH1          WarningType = "primary_keyword_not_in_h1"
+	WarningPrimaryKeywordNotInURL         WarningType = "primary_keyword_not_in_url"
+	WarningPrimaryKeywordNotInDescription WarningType = "primary_keyword_not_in_description"
+	WarningPrimaryKeywordNotInIntro       WarningType = "primary_keyword_not_in_intro"
 )
 
 const MaxAuditPages = 20
 
+// MaxRampUp bounds RampUpMs, workers still waiting to start hold up a stopping pool
+const MaxRampUp = PageTimeout
+
+// RetryWorkers is the concurrency used when re-auditing pages that timed out
+const RetryWorkers = 2
+
 // AuditResult contains information about all audited pages
 type AuditResult struct {
-	Pages    []string   `json:"pages"`
-	Warnings WarningMap `json:"warnings"`
+	// URL is where the crawl started, the landing page of the requested URL
+	URL       string       `json:"url"`
+	Pages     []string     `json:"pages"`
+	Warnings  WarningMap   `json:"warnings"`
+	LinkGraph LinkGraph    `json:"link_graph,omitempty"`
+	Summary   AuditSummary `json:"summary"`
+	// RobotsExcluded lists links that were intentionally not crawled because of robots.txt
+	RobotsExcluded []string `json:"robots_excluded"`
+	// ReportURL is the GCS object the result was exported to, see AuditRequest.ExportGCS
+	ReportURL string `json:"report_url,omitempty"`
+	// ReportError is why the export failed, the result is returned regardless
+	ReportError string `json:"report_error,omitempty"`
+	Tenant
+}
+
+// AuditSummary contains counters describing how the crawl went
+type AuditSummary struct {
+	// RescuedPages is the number of timed out pages that loaded on the retry pass
+	RescuedPages int `json:"rescued_pages"`
+	// PeakFrontier is the most pages that waited for room in the queue at once
+	PeakFrontier int `json:"peak_frontier"`
+	// CheckTimings adds up how long each check took over all pages
+	CheckTimings map[string]CheckTiming `json:"check_timings"`
+}
+
+// CheckTiming is the time spent on one check over an audit
+type CheckTiming struct {
+	Runs    int     `json:"runs"`
+	TotalMs float64 `json:"total_ms"`
 }
 
 // example: {"h1_missing": [["https://example.com"], ["https://example2.com"]], "title_too_long": [["https://example.com", "very long title"]]}
@@ -66,17 +139,155 @@ type PageAuditInfo struct {
 //   keywordMatches: Record<string, number>;
 // };
 
+// AuditProgress is the message of "progress" events published while an audit runs
+type AuditProgress struct {
+	Paused bool `json:"paused"`
+	// Queued is the number of distinct pages queued so far, including finished ones
+	Queued int `json:"queued"`
+	// Frontier is the number of pages waiting for room in the queue, see QueueOverflow
+	Frontier int `json:"frontier"`
+}
+
 // AuditRequest structure
 type AuditRequest struct {
 	URL      string   `json:"url"`
 	Keywords []string `json:"keywords"`
 	Checks   *Checks  `json:"checks"`
+	// PrimaryKeyword must appear in the title, H1, URL, meta description and the
+	// first 100 words of every page, Keywords are only checked for presence
+	PrimaryKeyword string `json:"primary_keyword"`
+	// SeedURLs are crawled alongside URL even if nothing links to them
+	SeedURLs []string `json:"seed_urls"`
+	// MaxDepth stops following links past this link depth, the start URL and seeds are
+	// depth 0 and the pages they link to depth 1. 0 crawls until MaxAuditPages.
+	MaxDepth int `json:"max_depth"`
+	// SitemapDiff reports sitemap pages no crawled page links to, and crawled pages
+	// missing from the sitemap. Only pages within MaxAuditPages are compared.
+	SitemapDiff bool `json:"sitemap_diff"`
+	// RespectRobots skips discovered links disallowed by robots.txt, defaults to true
+	RespectRobots *bool `json:"respect_robots"`
+	// SPARoutes discovers client-side routes of single-page apps
+	SPARoutes bool `json:"spa_routes"`
+	// LoadMore scrolls and clicks "load more" before reading infinite-scroll pages
+	LoadMore *LoadMore `json:"load_more"`
+	// DisableJavaScript audits pages with page scripts turned off, as non-rendering crawlers see them
+	DisableJavaScript bool `json:"disable_javascript"`
+	// CheckContentType sends a HEAD request for discovered links and skips non-HTML responses
+	CheckContentType bool `json:"check_content_type"`
+	// IgnoreQuery treats URLs differing only in their query string as the same page.
+	// Only use it for sites where params never change content (not search/pagination).
+	IgnoreQuery bool `json:"ignore_query"`
+	// TrackingParams are query params dropped when deduplicating URLs, on top of
+	// DefaultTrackingParams (utm_*, gclid, ...). A trailing "*" matches by prefix.
+	TrackingParams []string `json:"tracking_params"`
+	// DelayMs is the base delay between navigations to the same host, 0 uses HOST_DELAY
+	// (DefaultHostDelay if unset). It is randomized by ±JitterPercent so requests don't
+	// arrive at a fixed interval.
+	DelayMs       int `json:"delay_ms"`
+	JitterPercent int `json:"jitter_percent"`
+	// LinkCacheScope is "global" (default) to share link verdicts between audits,
+	// or "audit" to check every link fresh within this run
+	LinkCacheScope string `json:"link_cache_scope"`
+	// InsecureSkipVerify accepts self-signed certs, see insecure_hosts.go
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	// FollowStartRedirect crawls from where the start URL redirects to, defaults to true.
+	// Otherwise links on the landing page can all look off-host (http://x -> https://www.x).
+	FollowStartRedirect *bool `json:"follow_start_redirect"`
+	// RampUpMs starts workers one by one over this many milliseconds instead of
+	// opening CHROME_WORKERS tabs against the site at once
+	RampUpMs int `json:"ramp_up_ms"`
+	// QueueOverflow is what happens when link-dense pages fill the task queue: "block"
+	// (default) waits for workers, "frontier" keeps the extra links in memory instead.
+	// QueueSize sets the queue buffer up to MaxAuditPages, 0 uses twice CHROME_WORKERS.
+	QueueOverflow string `json:"queue_overflow"`
+	QueueSize     int    `json:"queue_size"`
+	// ExportGCS also writes the result to the GCS_BUCKET bucket, its URL is set as ReportURL
+	ExportGCS bool `json:"export_gcs"`
+	// Referer is sent with every page load and link check, e.g. a search engine URL
+	Referer string `json:"referer"`
+	// UserAgent replaces Chrome's User-Agent and Headers are added to every page load,
+	// including its subresource requests on other hosts, prefer Cookies for credentials.
+	// Link checks and robots.txt don't send them. Empty keeps Chrome's own User-Agent.
+	UserAgent string            `json:"user_agent"`
+	Headers   map[string]string `json:"headers"`
+	// Cookies are set before every page load, e.g. a session cookie to audit pages
+	// behind a login. Chrome only sends them to their domain.
+	Cookies []Cookie `json:"cookies"`
+	// WaitFor is how to tell a page is ready to audit, see validateWaitFor. Empty waits
+	// for the load event and gives scripts another 500ms.
+	WaitFor string `json:"wait_for"`
+	// ScrollToBottom scrolls through every page before it is audited to render lazy-loaded content
+	ScrollToBottom bool `json:"scroll_to_bottom"`
+	// Tenant tags the audit with project_id/client_id for logs and pubsub
+	Tenant
 }
 
 func (r *AuditRequest) Validate() error {
 	if r.URL == "" {
 		return errors.New("url is required")
 	}
+	if err := r.Tenant.Validate(); err != nil {
+		return err
+	}
+	if err := validateLinkCacheScope(r.LinkCacheScope); err != nil {
+		return err
+	}
+	if err := validatePacing(r.DelayMs, r.JitterPercent); err != nil {
+		return err
+	}
+	if r.RampUpMs < 0 || time.Duration(r.RampUpMs)*time.Millisecond > MaxRampUp {
+		return fmt.Errorf("ramp_up_ms must be between 0 and %d", MaxRampUp.Milliseconds())
+	}
+	if err := validateQueueOverflow(r.QueueOverflow); err != nil {
+		return err
+	}
+	// The size goes straight into make(chan), and an audit reads MaxAuditPages pages at most
+	if r.QueueSize < 0 || r.QueueSize > MaxAuditPages {
+		return fmt.Errorf("queue_size must be between 0 and %d", MaxAuditPages)
+	}
+	if r.MaxDepth < 0 {
+		return errors.New("max_depth must not be negative")
+	}
+	if err := validateGCSExport(r.ExportGCS); err != nil {
+		return err
+	}
+	if err := validateReferer(r.Referer); err != nil {
+		return err
+	}
+	if err := validateRequestHeaders(r.UserAgent, r.Headers); err != nil {
+		return err
+	}
+	if err := validateCookies(r.Cookies); err != nil {
+		return err
+	}
+	if err := validateWaitFor(r.WaitFor); err != nil {
+		return err
+	}
+	if r.LoadMore != nil {
+		if err := r.LoadMore.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(r.SeedURLs) > MaxAuditPages {
+		return fmt.Errorf("at most %d seed_urls are allowed", MaxAuditPages)
+	}
+	for _, seedURL := range r.SeedURLs {
+		if _, err := url.ParseRequestURI(seedURL); err != nil {
+			return fmt.Errorf("invalid seed url %q: %w", seedURL, err)
+		}
+	}
+	if r.InsecureSkipVerify {
+		if err := checkInsecureAllowed(append([]string{r.URL}, r.SeedURLs...)); err != nil {
+			return err
+		}
+	}
+	if r.Checks == nil {
+		checks := defaultChecks()
+		r.Checks = &checks
+	}
+	if err := r.Checks.Validate(); err != nil {
+		return err
+	}
 	// if r.Keywords == nil {
 	// 	return errors.New("keywords is required")
 	// }
@@ -86,45 +297,47 @@ func (r *AuditRequest) Validate() error {
 	return nil
 }
 
-// Audit crawls a website starting from the given URL, following same-host links
-func Audit(startURL string, taskId string, keywords []string, checks Checks) (*AuditResult, error) {
+// Audit crawls a website starting from the request URL, following same-host links.
+// Cancelling ctx cancels the audit, async jobs are cancelled through the job store.
+func Audit(ctx context.Context, req AuditRequest, taskId string) (*AuditResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, span := tracer.Start(ctx, "Audit", trace.WithAttributes(
+		attribute.String("url.full", req.URL),
+		attribute.String("audit.task_id", taskId),
+	))
+	defer span.End()
+	startURL := req.URL
+
 	// Parse the starting URL to get the host
 	_, err := url.Parse(startURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
+
+	// Crawl from the landing page so the same-host filter uses the host links actually point to
+	if req.FollowStartRedirect == nil || *req.FollowStartRedirect {
+		if landingURL, err := resolveRedirects(startURL, req.InsecureSkipVerify, req.Referer); err != nil {
+			req.Tenant.Logger().Warn("failed to resolve redirects", "url", startURL, "error", err)
+		} else {
+			startURL = landingURL
+		}
+	}
 	pubSubClient, err := NewPubSubClient(context.Background())
 	if err != nil {
 		return nil, err
 	}
 	defer pubSubClient.Close()
 
-	// Create a single Chrome instance (ExecAllocator) shared by all workers
-	opts := append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Headless,
-		chromedp.DisableGPU,
-		chromedp.NoSandbox,
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.Flag("mute-audio", true),
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-setuid-sandbox", true),
-		chromedp.Flag("no-zygote", true),
-		chromedp.Flag("disable-background-networking", true),
-		chromedp.Flag("disable-default-apps", true),
-		chromedp.Flag("disable-sync", true),
-		chromedp.Flag("disable-translate", true),
-		chromedp.Flag("blink-settings", "imagesEnabled=false"),
-		chromedp.Flag("disable-remote-fonts", true),
-		chromedp.Flag("disable-background-timer-throttling", true),
-		chromedp.Flag("disable-renderer-backgrounding", true),
-		chromedp.Flag("disable-backgrounding-occluded-windows", true),
-		chromedp.Flag("disable-renderer-backgrounding", true),
-		chromedp.Flag("disable-features", "BackForwardCache"),
-	)
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer allocCancel()
+	// Start a single Chrome instance shared by all workers, each page gets its own tab
+	browser, err := NewSharedBrowser(BrowserOptions{
+		IgnoreCertErrors: req.InsecureSkipVerify,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer browser.Close()
 
 	var WORKERS int
 	num, err := strconv.Atoi(os.Getenv("CHROME_WORKERS"))
@@ -135,82 +348,281 @@ func Audit(startURL string, taskId string, keywords []string, checks Checks) (*A
 	}
 
 	// Create worker pool with 10 concurrent workers
-	pool := NewWorkerPool[AuditPageResult](WORKERS)
+	// Cancelling ctx aborts the pages being audited and skips the queued ones
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pool := NewWorkerPool[AuditPageResult](ctx, WORKERS)
+	normalizeOptions := NormalizeOptions{IgnoreQuery: req.IgnoreQuery, TrackingParams: req.TrackingParams}
+	dedupKey := func(pageURL string) string {
+		return normalizeURL(pageURL, normalizeOptions)
+	}
+	pool.DedupBy(dedupKey)
+	pool.LogTo(req.Tenant.Logger())
+	pool.RampUp(time.Duration(req.RampUpMs) * time.Millisecond)
+	if req.QueueSize > 0 {
+		pool.QueueSize(req.QueueSize)
+	}
+	if req.QueueOverflow == QueueOverflowFrontier {
+		pool.SpillToFrontier()
+	}
 
-	pagesSoFar := 0
+	// Load robots.txt once, discovered links it disallows are never queued
+	var robots *RobotsRules
+	if req.RespectRobots == nil || *req.RespectRobots {
+		robots = fetchRobots(startURL)
+	}
+	robotsExcluded := []string{}
+
+	var pagesSoFar atomic.Int64
+	linkCache := linkCacheForScope(req.LinkCacheScope)
+	pacer := NewHostPacer(hostDelay(req.DelayMs), float64(req.JitterPercent)/100)
+
+	// Link depth of every queued page, the start URL is depth 0
+	depths := map[string]int{startURL: 0}
+	var depthsMu sync.RWMutex
+	depthOf := func(pageURL string) int {
+		depthsMu.RLock()
+		defer depthsMu.RUnlock()
+		return depths[pageURL]
+	}
 
-	// Define task function that audits a page using the shared allocator
-	taskFunc := func(pageURL string) (AuditPageResult, error) {
-		result := AuditPage(AuditPageParams{
-			Ctx:      allocCtx,
-			PageURL:  pageURL,
-			Keywords: keywords,
-			Checks:   checks,
-		})
-		pagesSoFar++
-		return result, nil
+	// Define task function that audits a page in a new tab of the shared browser
+	auditTask := func(timeout time.Duration) TaskFunction[AuditPageResult] {
+		return func(ctx context.Context, pageURL string) (AuditPageResult, error) {
+			browserCtx := browser.Context()
+			pageCtx, cancelPage := tabContext(browserCtx, ctx)
+			defer cancelPage()
+
+			params := AuditPageParams{
+				Ctx:      pageCtx,
+				PageURL:  pageURL,
+				Depth:    depthOf(pageURL),
+				Timeout:  timeout,
+				Keywords: req.Keywords,
+				Checks:   *req.Checks,
+
+				Pacer:              pacer,
+				PrimaryKeyword:     req.PrimaryKeyword,
+				LinkCache:          linkCache,
+				SPARoutes:          req.SPARoutes,
+				LoadMore:           req.LoadMore,
+				DisableJavaScript:  req.DisableJavaScript,
+				InsecureSkipVerify: req.InsecureSkipVerify,
+				Referer:            req.Referer,
+				UserAgent:          req.UserAgent,
+				Headers:            req.Headers,
+				Cookies:            req.Cookies,
+				WaitFor:            req.WaitFor,
+				ScrollToBottom:     req.ScrollToBottom,
+				Tenant:             req.Tenant,
+			}
+			result := AuditPage(params)
+
+			// A crash may have taken the whole browser down, relaunch it if needed and retry once
+			if result.ErrorType == ErrorChromeCrash && ctx.Err() == nil {
+				if err := browser.Recover(browserCtx); err != nil {
+					req.Tenant.Logger().Error("failed to recover chrome", "error", err)
+				} else {
+					retryCtx, cancelRetry := tabContext(browser.Context(), ctx)
+					defer cancelRetry()
+					params.Ctx = retryCtx
+					result = AuditPage(params)
+				}
+			}
+
+			observePage("audit", req.Tenant, auditPageOutcome(result))
+			// Async jobs report it in /audit/status
+			jobStore.SetProgress(taskId, int(pagesSoFar.Add(1)))
+			return result, nil
+		}
 	}
 
 	// Start the worker pool
-	pool.Start(taskFunc)
+	pool.Start(auditTask(PageTimeout))
+
+	// publishProgress tells subscribers whether the audit is paused
+	publishProgress := func() {
+		pubSubClient.Publish(PubSubMessage{
+			TaskID: taskId,
+			Event:  "progress",
+			Message: AuditProgress{
+				Paused:   pool.IsPaused(),
+				Queued:   pool.QueuedCount(),
+				Frontier: pool.FrontierSize(),
+			},
+			Tenant: req.Tenant,
+		})
+	}
+
+	// Cancelling aborts the pages in progress and returns right away, the crawl loop
+	// sees ctx is done and stops the pool. Jobs of the async API are cancelled through
+	// /audit/cancel, no pubsub needed.
+	jobStore.SetCancel(taskId, cancel)
 
 	unsubscribe, err := pubSubClient.Subscribe(taskId, func(data PubSubMessage) {
-		if data.Event == "cancel" {
-			// cancel whole audit
-			pool.Stop()
+		switch data.Event {
+		case "cancel":
+			cancel()
+		case "pause":
+			// stop starting pages, the frontier is kept for resume
+			pool.Pause()
+			publishProgress()
+		case "resume":
+			pool.Resume()
+			publishProgress()
 		}
 	})
 	defer unsubscribe()
 
-	// Add the starting URL
+	// Add the starting URL and explicit seeds, all at depth 0
 	pool.AddTask(startURL)
+	for _, seedURL := range req.SeedURLs {
+		if pool.QueuedCount() >= MaxAuditPages {
+			break
+		}
+		if pool.HasBeenProcessed(seedURL) {
+			continue
+		}
+		if !robots.Allowed(seedURL) {
+			if !slices.Contains(robotsExcluded, seedURL) {
+				robotsExcluded = append(robotsExcluded, seedURL)
+			}
+			continue
+		}
+
+		depthsMu.Lock()
+		depths[seedURL] = 0
+		depthsMu.Unlock()
+		pool.AddTask(seedURL)
+	}
+
+	// Seed pages listed in the sitemap too, so orphan pages get audited
+	parsedStart, _ := url.Parse(startURL)
+	sitemapURLs, err := fetchSitemapURLs(parsedStart.Scheme + "://" + parsedStart.Host)
+	if err != nil {
+		req.Tenant.Logger().Warn("failed to read sitemap", "host", parsedStart.Host, "error", err)
+	}
+	sameHostSitemapURLs := []string{}
+	for _, sitemapURL := range sitemapURLs {
+		if parsed, err := url.Parse(sitemapURL); err == nil && parsed.Host == parsedStart.Host {
+			sameHostSitemapURLs = append(sameHostSitemapURLs, sitemapURL)
+		}
+	}
+	for _, sitemapURL := range sameHostSitemapURLs {
+		if pool.QueuedCount() >= MaxAuditPages {
+			break
+		}
+
+		if pool.HasBeenProcessed(sitemapURL) {
+			continue
+		}
+		if !robots.Allowed(sitemapURL) {
+			if !slices.Contains(robotsExcluded, sitemapURL) {
+				robotsExcluded = append(robotsExcluded, sitemapURL)
+			}
+			continue
+		}
+
+		// Sitemap pages have no link depth, use the path depth like list audits do
+		depthsMu.Lock()
+		depths[sitemapURL] = urlPathDepth(sitemapURL)
+		depthsMu.Unlock()
+		pool.AddTask(sitemapURL)
+	}
 
 	// Process results as they come in, adding new links to the pool
 	// Keep checking until we've processed MaxAuditPages or no more tasks
+	resultsSeen := 0
+crawl:
 	for {
-		results := pool.GetResults()
+		// Only look at results that came in since the last pass
+		results := pool.GetNewResults()
+		resultsSeen += len(results)
 
 		// Check if we've reached the limit
-		if len(results) >= MaxAuditPages {
+		if resultsSeen >= MaxAuditPages {
 			break
 		}
 
-		// Add new links from completed results
-		hasNewLinks := false
+		// Collect the new links from completed results with the depth they'd be crawled at
+		var candidates []string
+		candidateDepths := make(map[string]int)
 		for _, taskResult := range results {
+			// The page asked crawlers not to follow its links
+			if taskResult.Result.NoFollow {
+				continue
+			}
+
+			linkDepth := depthOf(taskResult.Data) + 1
+			if req.MaxDepth > 0 && linkDepth > req.MaxDepth {
+				continue
+			}
 			for _, link := range taskResult.Result.Links {
-				// AddTask returns true if the task was added (not a duplicate)
-				if pool.AddTask(link) {
-					hasNewLinks = true
-					// Stop adding if we've reached the limit
-					if pool.HasBeenProcessed(link) && len(pool.processed) >= MaxAuditPages {
-						break
+				if _, ok := candidateDepths[link]; ok || pool.HasBeenProcessed(link) {
+					continue
+				}
+				if !robots.Allowed(link) {
+					if !slices.Contains(robotsExcluded, link) {
+						robotsExcluded = append(robotsExcluded, link)
 					}
+					continue
 				}
+				candidates = append(candidates, link)
+				candidateDepths[link] = linkDepth
 			}
 		}
 
-		// If no new links were added and we have results, we're done
-		if !hasNewLinks && len(results) > 0 {
-			// Give workers a moment to finish any pending tasks
-			time.Sleep(100 * time.Millisecond)
-			finalResults := pool.GetResults()
-			if len(finalResults) == len(results) {
-				break
+		// Extensionless URLs can still be PDFs or downloads, don't waste a tab on them
+		if req.CheckContentType {
+			candidates = filterHTMLPages(candidates, req.InsecureSkipVerify)
+		}
+
+		for _, link := range candidates {
+			// Record the depth before queueing so the worker sees it
+			depthsMu.Lock()
+			depths[link] = candidateDepths[link]
+			depthsMu.Unlock()
+
+			// AddTask returns true if the task was added (not a duplicate)
+			if pool.AddTask(link) {
+				// Stop adding if we've reached the limit
+				if pool.QueuedCount() >= MaxAuditPages {
+					break
+				}
 			}
 		}
 
-		// Brief sleep to avoid busy-waiting
-		time.Sleep(50 * time.Millisecond)
+		// Every queued page has a result and none of them added new links, we're done
+		if pool.Idle() && pool.ResultCount() == resultsSeen {
+			break
+		}
+
+		// Block until the next result. Cancelling ctx skips the queued pages without
+		// results, so the pool would never go idle.
+		select {
+		case _, ok := <-pool.ResultReady():
+			if !ok {
+				break crawl
+			}
+		case <-ctx.Done():
+			break crawl
+		}
 	}
 
 	// Stop the pool and get final results
 	pool.Stop()
 	taskResults := pool.GetResults()
 
+	// Give slow pages one more chance with a longer timeout
+	rescued := retryTimedOutPages(ctx, taskResults, auditTask(2*PageTimeout))
+
 	// Create maps to track H1s and titles across all pages
 	h1Map := make(map[string][]string)
 	titleMap := make(map[string][]string)
+	linkGraph := make(LinkGraph)
+	fingerprints := make(map[string]string)
+	checkTimings := make(map[string]CheckTiming)
 
 	// Convert TaskResults to PageAuditInfo and collect H1s/titles
 	pages := make([]PageAuditInfo, 0, len(taskResults))
@@ -219,23 +631,40 @@ func Audit(startURL string, taskId string, keywords []string, checks Checks) (*A
 
 		// Create PageAuditInfo from AuditPageResult
 		pageInfo := PageAuditInfo{
-			URL:      auditResult.Url,
-			Title:    auditResult.Title,
-			Warnings: auditResult.Warnings,
-			Error:    auditResult.Error,
+			URL:        auditResult.Url,
+			StatusCode: auditResult.StatusCode,
+			Title:      auditResult.Title,
+			Warnings:   auditResult.Warnings,
+			Error:      auditResult.Error,
 		}
 		pages = append(pages, pageInfo)
+		for check, ms := range auditResult.CheckTimings {
+			timing := checkTimings[check]
+			timing.Runs++
+			timing.TotalMs += ms
+			checkTimings[check] = timing
+		}
+		if auditResult.ContentFingerprint != "" {
+			fingerprints[auditResult.Url] = auditResult.ContentFingerprint
+		}
+
+		// Keep the page's outgoing same-host links for the site graph
+		linkGraph.AddPage(auditResult.Url, auditResult.Links)
 
-		// Collect H1 texts for duplicate detection
-		for _, h1Text := range auditResult.H1Texts {
-			if h1Text != "" {
-				h1Map[h1Text] = append(h1Map[h1Text], auditResult.Url)
+		// Empty shells all look alike, keep them out of the duplicate stats if asked to
+		if !auditResult.Empty || !req.Checks.ExcludeEmptyPages {
+			// Collect H1 texts for duplicate detection
+			for _, h1Text := range auditResult.H1Texts {
+				// A page repeating its own H1 is h1_multiple, not a duplicate
+				if h1Text != "" && !slices.Contains(h1Map[h1Text], auditResult.Url) {
+					h1Map[h1Text] = append(h1Map[h1Text], auditResult.Url)
+				}
 			}
-		}
 
-		// Collect title for duplicate detection
-		if auditResult.Title != "" {
-			titleMap[auditResult.Title] = append(titleMap[auditResult.Title], auditResult.Url)
+			// Collect title for duplicate detection
+			if auditResult.Title != "" {
+				titleMap[auditResult.Title] = append(titleMap[auditResult.Title], auditResult.Url)
+			}
 		}
 
 		// Limit to MaxAuditPages
@@ -244,6 +673,9 @@ func Audit(startURL string, taskId string, keywords []string, checks Checks) (*A
 		}
 	}
 
+	// Many missing pages served the same error template are almost certainly soft 404s
+	boostDuplicateSoftNotFound(pages, fingerprints)
+
 	pageUrls := make([]string, 0, len(pages))
 	allWarnings := make(map[WarningType][][]string)
 
@@ -254,18 +686,142 @@ func Audit(startURL string, taskId string, keywords []string, checks Checks) (*A
 		}
 	}
 
-	// warnings := make(WarningMap)
-	// h1Warnings := make([]string, 0)
-	// titleWarnings := make([]string, 0)
+	if startURL != req.URL {
+		allWarnings[WarningStartURLRedirects] = append(allWarnings[WarningStartURLRedirects], []string{req.URL, startURL})
+	}
 
-	// for h1Text, urls := range h1Map {
-	// 	if (len(urls) > 1) {
-	// 		h1Warnings = append(h1Warnings, h1Text)
-	// 	}
-	// }
+	// Without a sitemap every crawled page would be reported as missing from it
+	if req.SitemapDiff && len(sameHostSitemapURLs) > 0 {
+		diff := sitemapDiff(sameHostSitemapURLs, pageUrls, linkGraph, startURL, dedupKey)
+		mergeWarningMaps(allWarnings, diff)
+	}
+
+	// Titles shared by several pages, payload: the title, then the URLs using it
+	if shared := sharedValueWarnings(titleMap); len(shared) > 0 {
+		allWarnings[WarningTitleDuplicate] = shared
+	}
+
+	// H1s shared by several pages, payload: the H1 text, then the URLs using it
+	if shared := sharedValueWarnings(h1Map); len(shared) > 0 {
+		allWarnings[WarningH1Duplicate] = shared
+	}
+
+	result := &AuditResult{
+		URL:       startURL,
+		Pages:     pageUrls,
+		Warnings:  allWarnings,
+		LinkGraph: linkGraph,
+		Summary: AuditSummary{
+			RescuedPages: rescued,
+			PeakFrontier: pool.FrontierPeak(),
+			CheckTimings: checkTimings,
+		},
+		RobotsExcluded: robotsExcluded,
+		Tenant:         req.Tenant,
+	}
+
+	// A failed export mustn't throw away the crawl, the caller still gets the result.
+	// The export outlives a cancelled audit, it only writes what was crawled.
+	if req.ExportGCS {
+		exportCtx, cancelExport := context.WithTimeout(context.WithoutCancel(ctx), GCSExportTimeout)
+		reportURL, err := exportToGCS(exportCtx, taskId, req.Tenant, result)
+		cancelExport()
+		if err != nil {
+			req.Tenant.Logger().Error("failed to export audit to gcs", "error", err)
+			result.ReportError = err.Error()
+		} else {
+			result.ReportURL = reportURL
+		}
+	}
+
+	return result, nil
+}
+
+// sharedValueWarnings returns a payload for every value used by more than one page: the
+// value, then the URLs using it. valueURLs maps values to the pages using them.
+// Payloads are sorted by value.
+func sharedValueWarnings(valueURLs map[string][]string) [][]string {
+	warnings := [][]string{}
+	for _, value := range slices.Sorted(maps.Keys(valueURLs)) {
+		if urls := valueURLs[value]; len(urls) > 1 {
+			warnings = append(warnings, append([]string{value}, urls...))
+		}
+	}
+	return warnings
+}
+
+// retryTimedOutPages re-audits timed out pages at low concurrency and swaps in
+// the ones that load this time. Returns the number of rescued pages.
+func retryTimedOutPages(ctx context.Context, taskResults []TaskResult[AuditPageResult], taskFunc TaskFunction[AuditPageResult]) int {
+	indexes := make(map[string]int)
+	for i, taskResult := range taskResults {
+		if taskResult.Result.TimedOut {
+			indexes[taskResult.Data] = i
+		}
+	}
+	if len(indexes) == 0 {
+		return 0
+	}
+
+	retryPool := NewWorkerPool[AuditPageResult](ctx, RetryWorkers)
+	retryPool.Start(taskFunc)
+	for pageURL := range indexes {
+		retryPool.AddTask(pageURL)
+	}
+	retryPool.Stop()
+
+	rescued := 0
+	for _, retried := range retryPool.GetResults() {
+		if retried.Result.TimedOut || retried.Result.Error != "" {
+			continue
+		}
+		taskResults[indexes[retried.Data]] = retried
+		rescued++
+	}
+	return rescued
+}
+
+// resolveRedirects follows the redirects of rawURL and returns where it lands
+func resolveRedirects(rawURL string, insecureSkipVerify bool, referer string) (string, error) {
+	client := linkClientFor(rawURL, insecureSkipVerify)
+
+	var resp *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, rawURL, nil)
+		if err != nil {
+			return "", err
+		}
+		if referer != "" {
+			req.Header.Set("Referer", referer)
+		}
 
-	return &AuditResult{
-		Pages:    pageUrls,
-		Warnings: allWarnings,
-	}, nil
+		resp, err = client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+
+		// Some servers don't implement HEAD
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	return resp.Request.URL.String(), nil
+}
+
+// Queue overflow behaviours, see AuditRequest.QueueOverflow
+const (
+	QueueOverflowBlock    = "block"
+	QueueOverflowFrontier = "frontier"
+)
+
+// validateQueueOverflow returns an error for unknown queue_overflow values
+func validateQueueOverflow(overflow string) error {
+	switch overflow {
+	case "", QueueOverflowBlock, QueueOverflowFrontier:
+		return nil
+	default:
+		return fmt.Errorf("invalid queue_overflow %q, expected %q or %q", overflow, QueueOverflowBlock, QueueOverflowFrontier)
+	}
 }
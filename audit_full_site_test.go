@@ -0,0 +1,36 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSharedValueWarningsTitles(t *testing.T) {
+	titleMap := map[string][]string{
+		"Da Mario | Pizza": {"https://example.com/", "https://example.com/menu"},
+		"Contact":          {"https://example.com/contact"},
+	}
+
+	got := sharedValueWarnings(titleMap)
+	want := [][]string{{"Da Mario | Pizza", "https://example.com/", "https://example.com/menu"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSharedValueWarningsH1s(t *testing.T) {
+	h1Map := map[string][]string{
+		"Our menu":      {"https://example.com/menu", "https://example.com/menu/lunch"},
+		"Welcome":       {"https://example.com/", "https://example.com/about"},
+		"Opening hours": {"https://example.com/hours"},
+	}
+
+	got := sharedValueWarnings(h1Map)
+	want := [][]string{
+		{"Our menu", "https://example.com/menu", "https://example.com/menu/lunch"},
+		{"Welcome", "https://example.com/", "https://example.com/about"},
+	}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -4,37 +4,117 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strconv"
 	"sync"
-
-	"github.com/chromedp/chromedp"
 )
 
 // AuditRequest structure
 type AuditListRequest struct {
-	URLs         []string `json:"urls"`
-	Keywords     []string `json:"keywords"`
-	Checks       *Checks  `json:"checks"`
-	CheckedPaths []string `json:"checked_paths"`
+	URLs         []AuditListURL `json:"urls"`
+	Keywords     []string       `json:"keywords"`
+	Checks       *Checks        `json:"checks"`
+	CheckedPaths []string       `json:"checked_paths"`
+	// PrimaryKeyword must appear in the title, H1, URL, meta description and the
+	// first 100 words of every page, Keywords are only checked for presence
+	PrimaryKeyword string `json:"primary_keyword"`
+	// SPARoutes discovers client-side routes of single-page apps
+	SPARoutes bool `json:"spa_routes"`
+	// LoadMore scrolls and clicks "load more" before reading infinite-scroll pages
+	LoadMore *LoadMore `json:"load_more"`
+	// DisableJavaScript audits pages with page scripts turned off, as non-rendering crawlers see them
+	DisableJavaScript bool `json:"disable_javascript"`
+	// DelayMs is the base delay between navigations to the same host, 0 uses HOST_DELAY
+	// (DefaultHostDelay if unset). It is randomized by ±JitterPercent so requests don't
+	// arrive at a fixed interval.
+	DelayMs       int `json:"delay_ms"`
+	JitterPercent int `json:"jitter_percent"`
+	// LinkCacheScope is "global" (default) to share link verdicts between audits,
+	// or "audit" to check every link fresh within this run
+	LinkCacheScope string `json:"link_cache_scope"`
+	// InsecureSkipVerify accepts self-signed certs, see insecure_hosts.go
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	// Referer is sent with every page load and link check, e.g. a search engine URL
+	Referer string `json:"referer"`
+	// Tenant tags the audit with project_id/client_id for logs and pubsub
+	Tenant
+}
+
+// AuditListURL is a URL of a list audit. Checks and Keywords override the request's
+// for this URL only, e.g. keyword checks on landing pages. In JSON it is either a plain
+// URL string or {"url", "checks", "keywords"}.
+type AuditListURL struct {
+	URL      string   `json:"url"`
+	Checks   *Checks  `json:"checks,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+func (u *AuditListURL) UnmarshalJSON(data []byte) error {
+	var plainURL string
+	if err := json.Unmarshal(data, &plainURL); err == nil {
+		*u = AuditListURL{URL: plainURL}
+		return nil
+	}
+
+	// A type without the method, so this doesn't recurse
+	type auditListURL AuditListURL
+	return json.Unmarshal(data, (*auditListURL)(u))
+}
+
+// pageURLs returns the URLs to audit without their overrides
+func (r *AuditListRequest) pageURLs() []string {
+	urls := make([]string, 0, len(r.URLs))
+	for _, u := range r.URLs {
+		urls = append(urls, u.URL)
+	}
+	return urls
 }
 
 func (r *AuditListRequest) Validate() error {
 	if len(r.URLs) == 0 {
 		return errors.New("url is required")
 	}
-	if r.Checks == nil {
-		r.Checks = &Checks{
-			Headings:    true,
-			Title:       true,
-			Description: true,
-			Keywords:    true,
-			Images:      false,
-			Links:       false,
-			Security:    true,
+	for _, u := range r.URLs {
+		if u.URL == "" {
+			return errors.New("url is required for every entry of urls")
+		}
+		if u.Checks != nil {
+			if err := u.Checks.Validate(); err != nil {
+				return fmt.Errorf("%s: %w", u.URL, err)
+			}
+		}
+	}
+	if err := r.Tenant.Validate(); err != nil {
+		return err
+	}
+	if err := validateLinkCacheScope(r.LinkCacheScope); err != nil {
+		return err
+	}
+	if err := validatePacing(r.DelayMs, r.JitterPercent); err != nil {
+		return err
+	}
+	if err := validateReferer(r.Referer); err != nil {
+		return err
+	}
+	if r.LoadMore != nil {
+		if err := r.LoadMore.Validate(); err != nil {
+			return err
 		}
 	}
+	if r.InsecureSkipVerify {
+		if err := checkInsecureAllowed(r.pageURLs()); err != nil {
+			return err
+		}
+	}
+	if r.Checks == nil {
+		checks := defaultChecks()
+		r.Checks = &checks
+	}
+	if err := r.Checks.Validate(); err != nil {
+		return err
+	}
 	if r.Keywords == nil {
 		r.Keywords = []string{}
 	}
@@ -58,9 +138,7 @@ func auditListHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	query := r.URL.Query()
-	apiKey := query.Get("api_key")
-	if apiKey != os.Getenv("API_KEY") {
+	if !validAPIKey(r) {
 		http.Error(w, "Invalid API key", http.StatusUnauthorized)
 		return
 	}
@@ -75,6 +153,7 @@ func auditListHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	req.Tenant.CorrelationID = requestID(r)
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -89,54 +168,74 @@ func auditListHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(" "))
 	flusher.Flush()
 
-	opts := append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Headless,
-		chromedp.DisableGPU,
-		chromedp.NoSandbox,
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.Flag("mute-audio", true),
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-setuid-sandbox", true),
-		chromedp.Flag("no-zygote", true),
-		chromedp.Flag("disable-background-networking", true),
-		chromedp.Flag("disable-default-apps", true),
-		chromedp.Flag("disable-sync", true),
-		chromedp.Flag("disable-translate", true),
-		chromedp.Flag("blink-settings", "imagesEnabled=false"),
-		chromedp.Flag("disable-remote-fonts", true),
-		chromedp.Flag("disable-background-timer-throttling", true),
-		chromedp.Flag("disable-renderer-backgrounding", true),
-		chromedp.Flag("disable-backgrounding-occluded-windows", true),
-		chromedp.Flag("disable-renderer-backgrounding", true),
-		chromedp.Flag("disable-features", "BackForwardCache"),
-	)
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	allocCtx, allocCancel := newAllocator(context.Background(), BrowserOptions{
+		IgnoreCertErrors: req.InsecureSkipVerify,
+	})
 	defer allocCancel()
 
+	linkCache := linkCacheForScope(req.LinkCacheScope)
+	pacer := NewHostPacer(hostDelay(req.DelayMs), float64(req.JitterPercent)/100)
+
+	// Stops the tabs once the client is gone or the stream can't be written anymore
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// Tabs take the next URL from a shared queue rather than a fixed chunk each, so one
+	// slow page only holds up its own tab
+	queue := make(chan AuditListURL, len(req.URLs))
+	for _, u := range req.URLs {
+		queue <- u
+	}
+	close(queue)
+
 	results := make(chan AuditPageResult)
 	var wg sync.WaitGroup
 
-	dividedUrls := divideUrls(req.URLs, MAX_TABS)
-
-	for _, urls := range dividedUrls {
+	for range min(MAX_TABS, len(req.URLs)) {
 		wg.Go(func() {
-			for _, url := range urls {
-				select {
-				case <-r.Context().Done():
+			for u := range queue {
+				if ctx.Err() != nil {
 					return
-				default:
 				}
 
+				// Per-URL overrides replace the request's checks and keywords entirely
+				checks := *req.Checks
+				if u.Checks != nil {
+					checks = *u.Checks
+				}
+				keywords := req.Keywords
+				if u.Keywords != nil {
+					keywords = u.Keywords
+				}
+
+				// The page runs in allocCtx's browser but ends with ctx, including its
+				// pacer and tab slot waits
+				pageCtx, cancelPage := tabContext(allocCtx, ctx)
 				result := AuditPage(AuditPageParams{
-					Ctx:          allocCtx,
-					PageURL:      url,
-					Keywords:     req.Keywords,
-					Checks:       *req.Checks,
+					Ctx:          pageCtx,
+					PageURL:      u.URL,
+					Depth:        urlPathDepth(u.URL),
+					Keywords:     keywords,
+					Checks:       checks,
 					CheckedPaths: req.CheckedPaths,
+
+					Pacer:              pacer,
+					PrimaryKeyword:     req.PrimaryKeyword,
+					LinkCache:          linkCache,
+					SPARoutes:          req.SPARoutes,
+					LoadMore:           req.LoadMore,
+					DisableJavaScript:  req.DisableJavaScript,
+					InsecureSkipVerify: req.InsecureSkipVerify,
+					Referer:            req.Referer,
+					Tenant:             req.Tenant,
 				})
-				results <- result
+				cancelPage()
+				observePage("audit", req.Tenant, auditPageOutcome(result))
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
 			}
 		})
 	}
@@ -146,16 +245,24 @@ func auditListHandler(w http.ResponseWriter, r *http.Request) {
 		close(results)
 	}()
 
+	// This loop is the only writer of the stream. Results are written in the order the
+	// pages finish, not the order of urls, each one whole and followed by the separator,
+	// so clients match them by url. The status and headers went out with the first
+	// byte, so errors past this point can't become an HTTP error: a result that doesn't
+	// marshal is logged and skipped, a failed write ends the audit.
 	for result := range results {
 		output, err := json.Marshal(result)
 		if err != nil {
-			http.Error(w, "Audit failed: "+err.Error(), http.StatusInternalServerError)
+			req.Tenant.Logger().Error("failed to marshal audit result", "url", result.Url, "error", err)
+			continue
 		}
 
 		if _, err := w.Write(output); err != nil {
+			cancel()
 			return
 		}
 		if _, err := w.Write([]byte("___separator___")); err != nil {
+			cancel()
 			return
 		}
 
@@ -163,10 +270,10 @@ func auditListHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func divideUrls(urls []string, n int) [][]string {
+func divideUrls[T any](urls []T, n int) [][]T {
 	base := len(urls) / n
 	remainder := len(urls) % n
-	output := make([][]string, n)
+	output := make([][]T, n)
 	startAt := 0
 
 	for i := range n {
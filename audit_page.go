@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/chromedp/cdproto/inspector"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func getFileExtension(urlToVisit string) string {
@@ -29,6 +35,22 @@ func getFileExtension(urlToVisit string) string {
 	return fileExtension
 }
 
+// urlPathDepth counts the path segments of a URL, used as the page depth when there is no crawl
+func urlPathDepth(pageURL string) int {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return 0
+	}
+
+	depth := 0
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment != "" {
+			depth++
+		}
+	}
+	return depth
+}
+
 var pageExtensions = map[string]bool{
 	"html": true,
 	"htm":  true,
@@ -39,12 +61,51 @@ var pageExtensions = map[string]bool{
 	"jsp":  true,
 }
 
+// PageTimeout is how long a single page gets to load and be audited
+const PageTimeout = 30 * time.Second
+
 type AuditPageParams struct {
 	Ctx          context.Context
 	PageURL      string
+	Depth        int           // link depth from the crawl start, the start page is 0
+	Timeout      time.Duration // defaults to PageTimeout
 	Keywords     []string
 	Checks       Checks
 	CheckedPaths []string
+
+	// PrimaryKeyword gets the stricter placement checks, see checkPrimaryKeyword
+	PrimaryKeyword string
+
+	// Pacer delays navigations to the same host, nil means no delay
+	Pacer *HostPacer
+
+	// LinkCache holds broken-link verdicts, defaults to globalLinkCache
+	LinkCache *LinkCache
+
+	// DisableJavaScript audits the page with page scripts turned off
+	DisableJavaScript bool
+
+	// SPARoutes follows client-side router links and history API routes
+	SPARoutes bool
+
+	// LoadMore reveals infinite-scroll content before extraction, nil to read the page as loaded
+	LoadMore *LoadMore
+
+	// InsecureSkipVerify lets the link checker accept self-signed certs on private/allowlisted hosts
+	InsecureSkipVerify bool
+	// Referer is sent with the page load and its link checks
+	Referer string
+	// UserAgent and Headers are only sent with the page load and its subresources
+	UserAgent string
+	Headers   map[string]string
+	// Cookies are set in the tab before the page loads
+	Cookies []Cookie
+	// WaitFor replaces the default wait after the load event, see validateWaitFor
+	WaitFor string
+	// ScrollToBottom scrolls through the page before extraction to render lazy-loaded content
+	ScrollToBottom bool
+	// Tenant tags log lines for this page
+	Tenant Tenant
 }
 
 // AuditPageResult combines page info and discovered links
@@ -56,48 +117,184 @@ type AuditPageResult struct {
 	Title          string         `json:"title"`
 	Error          string         `json:"error"`
 	KeywordMatches map[string]int `json:"keywordMatches"`
+	TimedOut       bool           `json:"timedOut,omitempty"`
+	ErrorType      string         `json:"errorType,omitempty"`
+	ChecksRun      []string       `json:"checksRun"`
+	Depth          int            `json:"depth"`
+	// StatusCode is the HTTP status of the page's document response, 0 if none was seen
+	StatusCode int `json:"statusCode,omitempty"`
+	// TextRatio is the visible text length divided by the HTML length, 0 in fast meta mode
+	TextRatio float64 `json:"textRatio"`
+	// Har is the page's network activity, only recorded with Checks.Har
+	Har *HAR `json:"har,omitempty"`
+	// RobotsMeta is the robots meta tag content, NoFollow is set when it contains nofollow
+	// and tells the crawler not to follow the page's links. Only read with Checks.Robots.
+	RobotsMeta string `json:"robotsMeta,omitempty"`
+	NoFollow   bool   `json:"noFollow,omitempty"`
+	// CheckTimings is how long each check in ChecksRun took, in milliseconds
+	CheckTimings map[string]float64 `json:"checkTimings,omitempty"`
+	// Empty is set when Checks.EmptyPage found next to no text on the page
+	Empty bool `json:"empty,omitempty"`
+	// ContentFingerprint is only set on soft 404 pages, to find ones served from the same template
+	ContentFingerprint string `json:"-"`
 }
 
-// auditPage audits a single page and returns its info and same-host links
+// AuditPage audits a single page and returns its info and same-host links. The audit
+// is traced as a span in the trace of p.Ctx.
 func AuditPage(p AuditPageParams) AuditPageResult {
+	ctx, span := tracer.Start(p.Ctx, "AuditPage", trace.WithAttributes(attribute.String("url.full", p.PageURL)))
+	p.Ctx = ctx
+
+	result := auditPage(p)
+
+	span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+	switch {
+	case result.TimedOut:
+		endSpan(span, context.DeadlineExceeded)
+	case result.Error != "":
+		endSpan(span, errors.New(result.Error))
+	default:
+		endSpan(span, nil)
+	}
+	return result
+}
+
+// auditPage audits a single page and returns its info and same-host links
+func auditPage(p AuditPageParams) AuditPageResult {
 	fileExt := getFileExtension(p.PageURL)
 
 	if fileExt != "" && !pageExtensions[fileExt] {
 		return AuditPageResult{
-			Url: p.PageURL,
+			Url:       p.PageURL,
+			ChecksRun: []string{},
 		}
 	}
 
+	// Space out navigations to the same host
+	if err := p.Pacer.Wait(p.Ctx, p.PageURL); err != nil {
+		return failedPageResult(p, err)
+	}
+
+	// Wait for a free tab before the page timeout starts
+	if err := tabPool.Acquire(p.Ctx); err != nil {
+		return failedPageResult(p, err)
+	}
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = PageTimeout
+	}
+
 	// Context with timeout for this specific page
-	ctx, cancel := context.WithTimeout(p.Ctx, 30*time.Second)
+	ctx, cancel := context.WithTimeout(p.Ctx, timeout)
 	defer cancel()
 
-	// Create a new browser context from the shared allocator
+	// Create a new browser context from the shared allocator. The tab is closed and its
+	// slot freed once the page is read, before the JS-disabled snapshot waits for a slot
+	// of its own, so pages holding every slot can't wait on each other.
 	taskCtx, taskCancel := chromedp.NewContext(ctx)
-	defer taskCancel()
+	closeTab := sync.OnceFunc(func() {
+		taskCancel()
+		tabPool.Release()
+	})
+	defer closeTab()
+
+	tabCrashed := listenTabCrash(taskCtx, taskCancel)
+	statusCode := listenDocumentStatus(taskCtx)
+
+	var har func() *HAR
+	if p.Checks.Har {
+		har = recordHar(taskCtx)
+	}
 
 	var title string
 	var pageText string
 	var metaDesc string
+	var metaCounts struct {
+		Titles       int `json:"titles"`
+		Descriptions int `json:"descriptions"`
+	}
 	var linkHrefs []string
-	h1Texts := make([]string, 2)
+	var h1Texts []string
+	var headingLevels []int
 	keywordMatches := make(map[string]int)
 
-	err := chromedp.Run(taskCtx,
+	// Load phases are timed from here, startup ends once the tab is open
+	loadStart := time.Now()
+	actions := []chromedp.Action{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			observePageLoad("audit", "startup", loadStart)
+			return nil
+		}),
+		inspector.Enable(),
 		network.Enable(),
 		network.SetBlockedURLs([]string{
 			"*.png", "*.jpg", "*.jpeg", "*.gif", "*.webp",
 			"*.svg", "*.woff", "*.woff2", "*.ttf", "*.otf",
 			"*.mp4", "*.webm",
 		}),
+	}
+
+	// Record client-side routes so the crawler can follow them
+	var spaRoutes []string
+	if p.SPARoutes {
+		actions = append(actions, spaBeforeNavigate())
+	}
+	if p.DisableJavaScript {
+		actions = append(actions, disableJavaScript())
+	}
+	actions = append(actions, setRequestHeaders(p.Referer, p.UserAgent, p.Headers))
+	if len(p.Cookies) > 0 {
+		actions = append(actions, setCookies(p.Cookies, p.Tenant.Logger()))
+	}
+
+	// By default scripts get a moment to render after the load event
+	var wait chromedp.Action = chromedp.Tasks{
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.Sleep(500 * time.Millisecond),
+	}
+	if p.WaitFor != "" {
+		var waitSetup chromedp.Action
+		waitSetup, wait = pageWait(taskCtx, p.WaitFor)
+		actions = append(actions, waitSetup)
+	}
+
+	// Child spans of the page for navigating and reading it
+	spans := &pageSpans{ctx: p.Ctx}
 
+	actions = append(actions,
+		spans.phase("navigate"),
 		chromedp.Navigate(p.PageURL),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			observePageLoad("audit", "navigate", loadStart)
+			return nil
+		}),
 		chromedp.Poll(`document.readyState === "complete"`, nil),
-		chromedp.WaitReady("body", chromedp.ByQuery),
-		chromedp.Sleep(500*time.Millisecond),
+		wait,
+	)
 
-		chromedp.Text("body", &pageText, chromedp.NodeVisible, chromedp.ByQuery),
+	if p.SPARoutes {
+		actions = append(actions, spaAfterLoad(&spaRoutes))
+	}
+	if p.ScrollToBottom {
+		actions = append(actions, scrollToBottom())
+	}
+	if p.LoadMore != nil {
+		actions = append(actions, loadMoreContent(*p.LoadMore))
+	}
+
+	actions = append(actions, spans.phase("extract"))
+
+	// Fast meta mode skips pulling the full body text
+	var htmlLength int
+	if !p.Checks.FastMeta {
+		actions = append(actions,
+			chromedp.Text("body", &pageText, chromedp.NodeVisible, chromedp.ByQuery),
+			chromedp.EvaluateAsDevTools(`document.documentElement.outerHTML.length`, &htmlLength),
+		)
+	}
 
+	actions = append(actions,
 		// Get title
 		chromedp.Title(&title),
 
@@ -106,6 +303,13 @@ func AuditPage(p AuditPageParams) AuditPageResult {
 			(document.querySelector('meta[name="description"]') || {}).content || ""
 		`, &metaDesc),
 
+		// chromedp.Title only returns the first title, count them to catch duplicates.
+		// SVG icons have their own <title> elements which don't count.
+		chromedp.EvaluateAsDevTools(`({
+			titles: document.querySelectorAll('title:not(svg title)').length,
+			descriptions: document.querySelectorAll('meta[name="description"]').length,
+		})`, &metaCounts),
+
 		// Get H1 texts
 		chromedp.EvaluateAsDevTools(`
 			Array.from(document.querySelectorAll("h1"))
@@ -119,48 +323,313 @@ func AuditPage(p AuditPageParams) AuditPageResult {
 		`, &linkHrefs),
 	)
 
+	// Image requests are blocked above, but the <img> elements and their
+	// attributes are still in the DOM so they can be read without loading them
+	var imgAttrs []map[string]string
+	if p.Checks.Images {
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll("img")).map(el => {
+				const attrs = {src: el.getAttribute("src") ? el.src : (el.dataset.src || "")};
+				if (el.hasAttribute("alt")) attrs.alt = el.getAttribute("alt");
+				return attrs;
+			})
+		`, &imgAttrs))
+	}
+
+	var robotsMeta string
+	if p.Checks.Robots {
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll('meta[name="robots" i]'))
+			     .map(el => el.content || "").join(", ")
+		`, &robotsMeta))
+	}
+
+	var viewport *string
+	if p.Checks.Mobile {
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			(document.querySelector('meta[name="viewport"]') || {content: null}).content
+		`, &viewport))
+	}
+
+	var canonicalURLs []string
+	if p.Checks.Canonical {
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll('link[rel="canonical"]'))
+			     .map(el => el.href)
+		`, &canonicalURLs))
+	}
+
+	if p.Checks.Headings {
+		// Levels of all headings in document order, e.g. [1, 2, 4]
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll("h1,h2,h3,h4,h5,h6"))
+			     .map(el => Number(el.tagName[1]))
+		`, &headingLevels))
+	}
+
+	var missingAnchors []string
+	if p.Checks.Links && !p.Checks.FastMeta {
+		// Fragments of links to this same document with no element of that id or name.
+		// "#" and "#top" scroll to the top without a target.
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll("a[href*='#']"))
+			     .filter(el => el.hash && el.origin === location.origin && el.pathname === location.pathname && el.search === location.search)
+			     .map(el => el.hash)
+			     .filter(hash => {
+			         let id = hash.slice(1);
+			         try { id = decodeURIComponent(id); } catch (e) {}
+			         return id.toLowerCase() !== "top" && !document.getElementById(id) && document.getElementsByName(id).length === 0;
+			     })
+		`, &missingAnchors))
+	}
+
+	var resourceURLs []string
+	if p.Checks.Security {
+		// Subresources the page loads, <a> links are covered by checkLinkProtocol
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll(
+				'img[src], script[src], iframe[src], link[href][rel~="stylesheet" i], link[href][rel~="icon" i], link[href][rel~="preload" i], link[href][rel~="modulepreload" i]'
+			)).map(el => el.src || el.href)
+		`, &resourceURLs))
+	}
+
+	var ogTags map[string]string
+	var twitterTags map[string]string
+	if p.Checks.Social {
+		// The first tag wins, as with most scrapers
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll('meta[property^="og:"]'))
+			     .reduce((tags, el) => {
+			         const property = el.getAttribute("property").toLowerCase();
+			         if (!(property in tags)) tags[property] = el.content || "";
+			         return tags;
+			     }, {})
+		`, &ogTags))
+		// Many sites use property= instead of name= for twitter tags, X reads both
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll('meta[name^="twitter:"], meta[property^="twitter:"]'))
+			     .reduce((tags, el) => {
+			         const name = (el.getAttribute("name") || el.getAttribute("property")).toLowerCase();
+			         if (!(name in tags)) tags[name] = el.content || "";
+			         return tags;
+			     }, {})
+		`, &twitterTags))
+	}
+
+	var hasBreadcrumbs bool
+	var jsonLDBlocks []string
+	if p.Checks.StructuredData {
+		// Look for visual breadcrumbs as well as BreadcrumbList schema (JSON-LD or microdata)
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			!!document.querySelector('[aria-label="breadcrumb" i], .breadcrumb, .breadcrumbs, [itemtype*="BreadcrumbList"]') ||
+			Array.from(document.querySelectorAll('script[type="application/ld+json"]'))
+			     .some(el => el.textContent.includes("BreadcrumbList"))
+		`, &hasBreadcrumbs))
+		actions = append(actions, chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll('script[type="application/ld+json"]'))
+			     .map(el => el.textContent)
+		`, &jsonLDBlocks))
+	}
+
+	startTime := time.Now()
+	err := chromedp.Run(taskCtx, actions...)
+	spans.end(err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		p.Tenant.Logger().Warn("page load timed out", "url", p.PageURL, "error", err)
+		result := timedOutPageResult(p, time.Since(startTime))
+		result.StatusCode = statusCode()
+		// Slow pages are what the HAR is most useful for
+		if har != nil {
+			result.Har = har()
+		}
+		return result
+	}
 	if err != nil {
-		log.Println(p.PageURL, err)
-		return AuditPageResult{
-			Url:            p.PageURL,
-			Error:          err.Error(),
-			Warnings:       WarningMap{},
-			Links:          []string{},
-			H1Texts:        []string{},
-			KeywordMatches: keywordMatches,
+		p.Tenant.Logger().Warn("page load failed", "url", p.PageURL, "error", err)
+		result := failedPageResult(p, err)
+		result.StatusCode = statusCode()
+		if tabCrashed() || isCrashError(err) {
+			result.ErrorType = ErrorChromeCrash
+		}
+		if har != nil {
+			result.Har = har()
 		}
+		return result
+	}
+
+	observePageLoad("audit", "complete", loadStart)
+
+	linkHrefs = append(linkHrefs, spaRoutes...)
+
+	var textRatio float64
+	if htmlLength > 0 {
+		// outerHTML.length counts UTF-16 code units, runes are close enough for a ratio
+		textRatio = float64(utf8.RuneCountInString(pageText)) / float64(htmlLength)
 	}
 
 	// Run all validation checks and collect warnings
 	allWarnings := make(WarningMap)
 
+	// Error pages are reported whatever checks are on, they're why the rest looks wrong
+	status := statusCode()
+	mergeWarnings(allWarnings, checkStatusCode(status, p.PageURL))
+
+	// Record which checks actually ran so "no warnings" can't be mistaken for "not evaluated"
+	checksRun := []string{}
+
+	// Time each check so operators can see which ones to turn off for speed
+	checkTimings := make(map[string]float64)
+	recordCheck := func(name string, start time.Time) {
+		elapsed := time.Since(start)
+		checksRun = append(checksRun, name)
+		checkTimings[name] = float64(elapsed.Microseconds()) / 1000
+		observeCheckDuration(name, elapsed)
+	}
+	runCheck := func(name string, check func()) {
+		start := time.Now()
+		check()
+		recordCheck(name, start)
+	}
+
 	// Merge warnings from each check
 	if p.Checks.Headings {
-		mergeWarnings(allWarnings, checkH1(h1Texts, p.PageURL))
+		runCheck("headings", func() {
+			mergeWarnings(allWarnings, checkH1(h1Texts, p.PageURL))
+			mergeWarnings(allWarnings, checkHeadingOrder(headingLevels, p.PageURL))
+		})
 	}
 	if p.Checks.Title {
-		mergeWarnings(allWarnings, checkTitle(title, p.PageURL))
+		runCheck("title", func() {
+			minLen, maxLen := p.Checks.titleBounds()
+			mergeWarnings(allWarnings, checkTitle(title, metaCounts.Titles, minLen, maxLen, p.PageURL))
+		})
 	}
 	if p.Checks.Description {
-		mergeWarnings(allWarnings, checkDescription(metaDesc, p.PageURL))
+		runCheck("description", func() {
+			minLen, maxLen := p.Checks.descBounds()
+			mergeWarnings(allWarnings, checkDescription(metaDesc, metaCounts.Descriptions, minLen, maxLen, p.PageURL))
+		})
 	}
-	if p.Checks.Links {
-		checkedPathsMap := make(map[string]bool)
-		if p.CheckedPaths != nil {
-			for _, checkedPath := range p.CheckedPaths {
-				checkedPathsMap[checkedPath] = true
+	if p.Checks.Links && !p.Checks.FastMeta {
+		runCheck("links", func() {
+			checkedPathsMap := make(map[string]bool)
+			if p.CheckedPaths != nil {
+				for _, checkedPath := range p.CheckedPaths {
+					checkedPathsMap[checkedPath] = true
+				}
 			}
-		}
 
-		mergeWarnings(allWarnings, checkBrokenLinks(p.PageURL, linkHrefs, checkedPathsMap))
+			_, linkSpan := tracer.Start(p.Ctx, "check_links", trace.WithAttributes(attribute.Int("links.count", len(linkHrefs))))
+			mergeWarnings(allWarnings, checkBrokenLinks(p.PageURL, linkHrefs, checkedPathsMap, LinkCheckOptions{
+				Cache:              p.LinkCache,
+				InsecureSkipVerify: p.InsecureSkipVerify,
+				Referer:            p.Referer,
+				RecordRedirects:    p.Checks.RedirectChains,
+			}))
+			linkSpan.End()
+			mergeWarnings(allWarnings, checkBrokenAnchors(missingAnchors, p.PageURL))
+		})
 	}
 	if p.Checks.Security {
-		mergeWarnings(allWarnings, checkLinkProtocol(linkHrefs, p.PageURL))
+		runCheck("security", func() {
+			mergeWarnings(allWarnings, checkSSL(p.PageURL))
+			mergeWarnings(allWarnings, checkLinkProtocol(linkHrefs, p.PageURL))
+			mergeWarnings(allWarnings, checkMixedContent(resourceURLs, p.PageURL))
+		})
+	}
+	if p.Checks.Images {
+		runCheck("images", func() {
+			mergeWarnings(allWarnings, checkImageAltText(imgAttrs, p.PageURL))
+		})
+	}
+	if p.Checks.JSContent && !p.DisableJavaScript && !p.Checks.FastMeta {
+		// Compare with what crawlers that don't render JavaScript get
+		start := time.Now()
+		closeTab()
+		noJSWords, noJSLinks, err := noJSSnapshot(ctx, p)
+		if err == nil {
+			mergeWarnings(allWarnings, checkJSContent(len(strings.Fields(pageText)), noJSWords, linkHrefs, noJSLinks, p.PageURL))
+			recordCheck("js_content", start)
+		}
+	}
+	if p.Checks.StructuredData {
+		runCheck("structured_data", func() {
+			mergeWarnings(allWarnings, checkBreadcrumbs(hasBreadcrumbs, p.Depth, p.PageURL))
+			mergeWarnings(allWarnings, checkStructuredData(jsonLDBlocks, p.PageURL))
+		})
+	}
+	noFollow := false
+	if p.Checks.Robots {
+		runCheck("robots", func() {
+			mergeWarnings(allWarnings, checkRobotsMeta(robotsMeta, p.PageURL))
+			directives := parseRobotsMeta(robotsMeta)
+			noFollow = slices.Contains(directives, "nofollow") || slices.Contains(directives, "none")
+		})
 	}
-	if p.Checks.Keywords && len(p.Keywords) > 0 {
-		checkKeywords(title+" "+pageText, p.Keywords, keywordMatches)
+	if p.Checks.Mobile {
+		runCheck("mobile", func() {
+			mergeWarnings(allWarnings, checkViewport(viewport, p.PageURL))
+		})
+	}
+	if p.Checks.Social {
+		runCheck("social", func() {
+			mergeWarnings(allWarnings, checkOpenGraph(ogTags, p.PageURL))
+			mergeWarnings(allWarnings, checkTwitterCard(twitterTags, ogTags, p.PageURL))
+		})
+	}
+	if p.Checks.Canonical {
+		runCheck("canonical", func() {
+			mergeWarnings(allWarnings, checkCanonical(canonicalURLs, p.PageURL))
+			if len(canonicalURLs) > 0 {
+				mergeWarnings(allWarnings, checkCanonicalTarget(canonicalURLs[0], p.PageURL, p.InsecureSkipVerify))
+			}
+		})
+	}
+	empty := false
+	if p.Checks.EmptyPage && !p.Checks.FastMeta {
+		runCheck("empty_page", func() {
+			minWords, minBytes := p.Checks.emptyPageBounds()
+			mergeWarnings(allWarnings, checkEmptyPage(pageText, minWords, minBytes, p.PageURL))
+			empty = isEmptyPage(pageText, minWords, minBytes)
+		})
+	}
+	// Empty pages already have their own warning
+	if p.Checks.ThinContent && !p.Checks.FastMeta && !empty {
+		runCheck("thin_content", func() {
+			mergeWarnings(allWarnings, checkWordCount(pageText, orDefault(p.Checks.ThinContentMinWords, DefaultThinContentMinWords), p.PageURL))
+		})
+	}
+	if p.Checks.ContentRatio && !p.Checks.FastMeta && !(empty && p.Checks.ExcludeEmptyPages) {
+		runCheck("content_ratio", func() {
+			mergeWarnings(allWarnings, checkTextRatio(textRatio, p.PageURL))
+		})
+	}
+	fingerprint := ""
+	// A page that answered 4xx/5xx is a real error page, not a soft one
+	if p.Checks.SoftNotFound && !p.Checks.FastMeta && status < 400 {
+		runCheck("soft_not_found", func() {
+			softNotFound := checkSoftNotFound(title, h1Texts, pageText, p.Checks.softNotFoundPhrases(), p.PageURL)
+			if len(softNotFound) > 0 {
+				fingerprint = contentFingerprint(pageText)
+			}
+			mergeWarnings(allWarnings, softNotFound)
+		})
+	}
+	if p.Checks.Keywords && p.PrimaryKeyword != "" {
+		runCheck("primary_keyword", func() {
+			mergeWarnings(allWarnings, checkPrimaryKeyword(p.PrimaryKeyword, title, h1Texts, metaDesc, pageText, p.PageURL))
+		})
+	}
+	if p.Checks.Keywords && !p.Checks.FastMeta && len(p.Keywords) > 0 {
+		runCheck("keywords", func() {
+			checkKeywords(title+" "+pageText, p.Keywords, keywordMatches)
+			mergeWarnings(allWarnings, checkKeywordsMissing(p.Keywords, keywordMatches, p.PageURL))
+		})
 	}
 
+	// A check running twice or overlapping checks must not count the same problem twice
+	dedupWarnings(allWarnings)
+
 	// Filter links to only include same-host URLs
 	sameHostLinks := []string{}
 	parsedBase, _ := url.Parse(p.PageURL)
@@ -176,13 +645,59 @@ func AuditPage(p AuditPageParams) AuditPageResult {
 		}
 	}
 
-	return AuditPageResult{
+	result := AuditPageResult{
 		Url:            p.PageURL,
 		Title:          title,
 		Warnings:       allWarnings,
 		Links:          sameHostLinks,
 		H1Texts:        h1Texts,
 		KeywordMatches: keywordMatches,
+		ChecksRun:      checksRun,
+		Depth:          p.Depth,
+		StatusCode:     status,
+		TextRatio:      textRatio,
+		RobotsMeta:     robotsMeta,
+		NoFollow:       noFollow,
+		Empty:          empty,
+		CheckTimings:   checkTimings,
+
+		ContentFingerprint: fingerprint,
+	}
+	if har != nil {
+		result.Har = har()
+	}
+	return result
+}
+
+// failedPageResult is returned when a page couldn't be loaded
+func failedPageResult(p AuditPageParams, err error) AuditPageResult {
+	return AuditPageResult{
+		Url:            p.PageURL,
+		Error:          err.Error(),
+		Warnings:       WarningMap{},
+		Links:          []string{},
+		H1Texts:        []string{},
+		KeywordMatches: make(map[string]int),
+		TimedOut:       errors.Is(err, context.DeadlineExceeded),
+		ChecksRun:      []string{},
+		Depth:          p.Depth,
+	}
+}
+
+// timedOutPageResult reports a page that didn't load in time as a warning rather than an
+// error, so clients can tell slow pages from crawl failures
+func timedOutPageResult(p AuditPageParams, elapsed time.Duration) AuditPageResult {
+	return AuditPageResult{
+		Url: p.PageURL,
+		Warnings: WarningMap{
+			WarningTimeoutPageLoad: {{p.PageURL, elapsed.Round(time.Millisecond).String()}},
+		},
+		Links:          []string{},
+		H1Texts:        []string{},
+		KeywordMatches: make(map[string]int),
+		TimedOut:       true,
+		ChecksRun:      []string{},
+		Depth:          p.Depth,
 	}
 }
 
@@ -191,3 +706,23 @@ func mergeWarnings(allWarnings WarningMap, pageWarnings map[WarningType][]string
 		allWarnings[warningType] = append(allWarnings[warningType], warnings)
 	}
 }
+
+// dedupWarnings drops repeated identical warnings of the same type, keeping the first
+func dedupWarnings(warnings WarningMap) {
+	for warningType, entries := range warnings {
+		unique := entries[:0]
+		for _, entry := range entries {
+			if !slices.ContainsFunc(unique, func(seen []string) bool { return slices.Equal(seen, entry) }) {
+				unique = append(unique, entry)
+			}
+		}
+		warnings[warningType] = unique
+	}
+}
+
+// mergeWarningMaps appends all warnings of src to dst
+func mergeWarningMaps(dst WarningMap, src WarningMap) {
+	for warningType, warnings := range src {
+		dst[warningType] = append(dst[warningType], warnings...)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDedupWarnings(t *testing.T) {
+	warnings := make(WarningMap)
+	// The same check run twice, plus another page's link breaking the same way
+	mergeWarnings(warnings, checkH1(nil, testPageURL))
+	mergeWarnings(warnings, checkH1(nil, testPageURL))
+	mergeWarnings(warnings, map[WarningType][]string{WarningLinksBroken: {testPageURL, "https://example.com/a"}})
+	mergeWarnings(warnings, map[WarningType][]string{WarningLinksBroken: {testPageURL, "https://example.com/b"}})
+	mergeWarnings(warnings, map[WarningType][]string{WarningLinksBroken: {testPageURL, "https://example.com/a"}})
+
+	dedupWarnings(warnings)
+
+	for warningType, entries := range warnings {
+		for i, entry := range entries {
+			for _, other := range entries[i+1:] {
+				if slices.Equal(entry, other) {
+					t.Errorf("%s has %v more than once", warningType, entry)
+				}
+			}
+		}
+	}
+
+	if got := len(warnings[WarningH1Missing]); got != 1 {
+		t.Errorf("got %d h1_missing entries, want 1", got)
+	}
+	want := [][]string{{testPageURL, "https://example.com/a"}, {testPageURL, "https://example.com/b"}}
+	if got := warnings[WarningLinksBroken]; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got links_broken %v, want %v in order", got, want)
+	}
+}
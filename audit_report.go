@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AuditReport is what the HTML report template renders
+type AuditReport struct {
+	URL         string
+	GeneratedAt time.Time
+	Pages       int
+	Warnings    int
+	Sections    []AuditReportSection
+}
+
+// AuditReportSection lists the warnings of one type, each row is a WarningMap payload
+type AuditReportSection struct {
+	Type WarningType
+	Rows [][]string
+}
+
+// newAuditReport groups the warnings of result by type, most frequent type first
+func newAuditReport(result *AuditResult) AuditReport {
+	report := AuditReport{
+		URL:         result.URL,
+		GeneratedAt: time.Now(),
+		Pages:       len(result.Pages),
+	}
+	for warningType, rows := range result.Warnings {
+		if len(rows) == 0 {
+			continue
+		}
+		report.Warnings += len(rows)
+		report.Sections = append(report.Sections, AuditReportSection{Type: warningType, Rows: rows})
+	}
+	slices.SortFunc(report.Sections, func(a, b AuditReportSection) int {
+		return cmp.Or(cmp.Compare(len(b.Rows), len(a.Rows)), cmp.Compare(a.Type, b.Type))
+	})
+	return report
+}
+
+// isLink reports whether a payload value is a URL that can be linked to. html/template
+// still escapes the href and drops unsafe schemes.
+func isLink(value string) bool {
+	return strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://")
+}
+
+// auditReportTemplate is a self-contained page, no external CSS or scripts. Titles,
+// descriptions and URLs come from the audited site and are escaped by html/template.
+var auditReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"isLink": isLink,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Audit report for {{.URL}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+h1 { font-size: 1.5rem; word-break: break-all; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 0.3rem 0.6rem; text-align: left; vertical-align: top; word-break: break-all; }
+th { background: #f4f4f4; }
+.summary td:last-child { text-align: right; }
+.muted { color: #666; }
+</style>
+</head>
+<body>
+<h1>Audit report for {{.URL}}</h1>
+<p class="muted">Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+
+<h2>Summary</h2>
+<table class="summary">
+<tr><th>Pages crawled</th><td>{{.Pages}}</td></tr>
+<tr><th>Warnings</th><td>{{.Warnings}}</td></tr>
+{{- range .Sections}}
+<tr><td><a href="#{{.Type}}">{{.Type}}</a></td><td>{{len .Rows}}</td></tr>
+{{- end}}
+</table>
+
+{{range .Sections}}
+<h2 id="{{.Type}}">{{.Type}} <span class="muted">({{len .Rows}})</span></h2>
+<table>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{if isLink .}}<a href="{{.}}">{{.}}</a>{{else}}{{.}}{{end}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{else}}
+<p>No warnings.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// writeAuditReport renders the report before writing anything, so a template error
+// can still become a 500
+func writeAuditReport(w http.ResponseWriter, report AuditReport) {
+	var buf bytes.Buffer
+	if err := auditReportTemplate.Execute(&buf, report); err != nil {
+		http.Error(w, "failed to render report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
+
+// auditReportHandler returns an audit as a self-contained HTML page. POST runs the
+// audit from an AuditRequest body, GET with ?job_id= renders a finished async audit.
+func auditReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		job, ok := requestedJob(w, r)
+		if !ok {
+			return
+		}
+		result, err := job.Result()
+		if err != nil {
+			http.Error(w, "failed to read audit result: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if result == nil {
+			http.Error(w, "audit is "+string(job.Status)+", the report is only available once it is done", http.StatusConflict)
+			return
+		}
+		writeAuditReport(w, newAuditReport(result))
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validAPIKey(r) {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	var req AuditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Tenant.CorrelationID = requestID(r)
+
+	// The task ID keys job progress, cancellation and the pubsub channel, so it must not
+	// come from the client, who could pass the ID of someone else's async job
+	result, err := Audit(r.Context(), req, newCorrelationID())
+	if err != nil {
+		req.Tenant.Logger().Error("audit failed", "error", err)
+		http.Error(w, "Audit failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeAuditReport(w, newAuditReport(result))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// AuditValidateResponse lists every problem found with an audit request, OK when there are none
+type AuditValidateResponse struct {
+	OK     bool     `json:"ok"`
+	Errors []string `json:"errors"`
+}
+
+// validateAudit runs the cheap checks an audit would otherwise only fail on after crawling
+func validateAudit(req AuditRequest) []string {
+	problems := []string{}
+
+	if err := req.Validate(); err != nil {
+		// The remaining checks need a usable request
+		return append(problems, err.Error())
+	}
+
+	for _, keyword := range req.Keywords {
+		if _, err := getRegex(keyword); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid keyword %q: %v", keyword, err))
+		}
+	}
+
+	// One HEAD request tells us whether the start URL is reachable at all
+	headReq, err := http.NewRequest(http.MethodHead, req.URL, nil)
+	if err != nil {
+		return append(problems, fmt.Sprintf("invalid url: %v", err))
+	}
+	resp, err := linkClientFor(req.URL, req.InsecureSkipVerify).Do(headReq)
+	if err != nil {
+		return append(problems, fmt.Sprintf("url is unreachable: %v", err))
+	}
+	resp.Body.Close()
+
+	// Some servers don't implement HEAD, they are still reachable
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+		problems = append(problems, fmt.Sprintf("url returned status %d", resp.StatusCode))
+	}
+
+	return problems
+}
+
+// auditValidateHandler checks an audit request without crawling anything
+func auditValidateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validAPIKey(r) {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	var req AuditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	problems := validateAudit(req)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	jsonErr := json.NewEncoder(w).Encode(AuditValidateResponse{
+		OK:     len(problems) == 0,
+		Errors: problems,
+	})
+	if jsonErr != nil {
+		http.Error(w, jsonErr.Error(), http.StatusInternalServerError)
+		return
+	}
+}
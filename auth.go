@@ -0,0 +1,19 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+)
+
+// validAPIKey reports whether the api_key query param matches API_KEY. The comparison
+// takes as long however much of the key matches, and with API_KEY unset every request
+// is rejected rather than callers sending no key being let in.
+func validAPIKey(r *http.Request) bool {
+	expected := os.Getenv("API_KEY")
+	if expected == "" {
+		return false
+	}
+	apiKey := r.URL.Query().Get("api_key")
+	return subtle.ConstantTimeCompare([]byte(apiKey), []byte(expected)) == 1
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidAPIKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		envKey string
+		target string
+		want   bool
+	}{
+		{"empty env rejects a missing key", "", "/scrape", false},
+		{"empty env rejects an empty key", "", "/scrape?api_key=", false},
+		{"empty env rejects any key", "", "/scrape?api_key=secret", false},
+		{"matching key", "secret", "/scrape?api_key=secret", true},
+		{"wrong key", "secret", "/scrape?api_key=secreT", false},
+		{"prefix of the key", "secret", "/scrape?api_key=sec", false},
+		{"missing key", "secret", "/scrape", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("API_KEY", tt.envKey)
+			r := httptest.NewRequest("GET", tt.target, nil)
+			if got := validAPIKey(r); got != tt.want {
+				t.Errorf("validAPIKey(%s) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
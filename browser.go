@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// BrowserOptions tweak how the shared Chrome instance is launched
+type BrowserOptions struct {
+	// IgnoreCertErrors makes Chrome accept invalid/self-signed certificates.
+	// Only set this for hosts that passed checkInsecureAllowed.
+	IgnoreCertErrors bool
+}
+
+// newAllocator creates a single Chrome instance (ExecAllocator) shared by all tabs of a request
+func newAllocator(parent context.Context, o BrowserOptions) (context.Context, context.CancelFunc) {
+	opts := append(
+		chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Headless,
+		chromedp.DisableGPU,
+		chromedp.NoSandbox,
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.Flag("mute-audio", true),
+		chromedp.Flag("no-first-run", true),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("disable-setuid-sandbox", true),
+		chromedp.Flag("no-zygote", true),
+		chromedp.Flag("disable-background-networking", true),
+		chromedp.Flag("disable-default-apps", true),
+		chromedp.Flag("disable-sync", true),
+		chromedp.Flag("disable-translate", true),
+		chromedp.Flag("blink-settings", "imagesEnabled=false"),
+		chromedp.Flag("disable-remote-fonts", true),
+		chromedp.Flag("disable-background-timer-throttling", true),
+		chromedp.Flag("disable-renderer-backgrounding", true),
+		chromedp.Flag("disable-backgrounding-occluded-windows", true),
+		chromedp.Flag("disable-renderer-backgrounding", true),
+		chromedp.Flag("disable-features", "BackForwardCache"),
+	)
+	if o.IgnoreCertErrors {
+		opts = append(opts, chromedp.Flag("ignore-certificate-errors", true))
+	}
+
+	return chromedp.NewExecAllocator(parent, opts...)
+}
+
+// listenDocumentStatus records the HTTP status of the first document response in the tab.
+// Must be called before chromedp.Run, the returned func reads the status (0 if none was seen).
+func listenDocumentStatus(taskCtx context.Context) func() int {
+	var status atomic.Int64
+	chromedp.ListenTarget(taskCtx, func(ev interface{}) {
+		if e, ok := ev.(*network.EventResponseReceived); ok && e.Type == network.ResourceTypeDocument {
+			status.CompareAndSwap(0, e.Response.Status)
+		}
+	})
+	return func() int {
+		return int(status.Load())
+	}
+}
+
+// ChromeStartAttempts is how many times startBrowser tries to launch Chrome
+const ChromeStartAttempts = 3
+
+// startBrowser launches Chrome and returns a context that tabs are created from.
+// Launching fails now and then under memory pressure, so it is retried with a growing delay.
+func startBrowser(parent context.Context, o BrowserOptions) (context.Context, context.CancelFunc, error) {
+	var lastErr error
+	for attempt := 1; attempt <= ChromeStartAttempts; attempt++ {
+		allocCtx, allocCancel := newAllocator(parent, o)
+		browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+
+		// Running without actions starts the browser
+		lastErr = chromedp.Run(browserCtx)
+		if lastErr == nil {
+			return browserCtx, func() {
+				browserCancel()
+				allocCancel()
+			}, nil
+		}
+		browserCancel()
+		allocCancel()
+
+		if attempt < ChromeStartAttempts {
+			slog.Warn("failed to start chrome", "attempt", attempt, "max_attempts", ChromeStartAttempts, "error", lastErr)
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+		}
+	}
+	return nil, nil, fmt.Errorf("failed to start chrome after %d attempts: %w", ChromeStartAttempts, lastErr)
+}
+
+// SharedBrowser is a Chrome instance shared by the workers of an audit (or by all scrape
+// requests, see scrapeBrowser), relaunched if it crashes
+type SharedBrowser struct {
+	opts   BrowserOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+}
+
+// NewSharedBrowser launches Chrome, see startBrowser
+func NewSharedBrowser(o BrowserOptions) (*SharedBrowser, error) {
+	ctx, cancel, err := startBrowser(context.Background(), o)
+	if err != nil {
+		return nil, err
+	}
+	return &SharedBrowser{
+		opts:   o,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Context returns the browser context new tabs are created from
+func (b *SharedBrowser) Context() context.Context {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ctx
+}
+
+// Recover relaunches Chrome after a crash if the browser behind crashedCtx no longer responds
+func (b *SharedBrowser) Recover(crashedCtx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Another worker already relaunched it
+	if b.ctx != crashedCtx {
+		return nil
+	}
+
+	// Usually only the tab died, check whether the browser can still open one
+	probeCtx, probeCancel := context.WithTimeout(b.ctx, 5*time.Second)
+	defer probeCancel()
+	tabCtx, tabCancel := chromedp.NewContext(probeCtx)
+	defer tabCancel()
+	if chromedp.Run(tabCtx) == nil {
+		return nil
+	}
+
+	slog.Warn("chrome stopped responding, relaunching")
+	b.cancel()
+	ctx, cancel, err := startBrowser(context.Background(), b.opts)
+	if err != nil {
+		return err
+	}
+	b.ctx, b.cancel = ctx, cancel
+	return nil
+}
+
+// Close shuts Chrome down
+func (b *SharedBrowser) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cancel()
+}
+
+// tabContext derives a context from the browser context for a new tab that is also
+// cancelled when ctx is, so cancelling a request closes its tabs right away. The tab
+// continues the trace of ctx.
+func tabContext(browserCtx context.Context, ctx context.Context) (context.Context, context.CancelFunc) {
+	tabCtx, cancel := context.WithCancel(withSpan(browserCtx, ctx))
+	stop := context.AfterFunc(ctx, cancel)
+	return tabCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// ErrorChromeCrash is the AuditPageResult.ErrorType of pages whose tab or browser crashed
+const ErrorChromeCrash = "chrome_crash"
+
+// listenTabCrash cancels the tab as soon as its renderer crashes instead of waiting for the
+// page timeout. Must be called before chromedp.Run, the returned func reports whether it crashed.
+func listenTabCrash(taskCtx context.Context, taskCancel context.CancelFunc) func() bool {
+	var crashed atomic.Bool
+	chromedp.ListenTarget(taskCtx, func(ev interface{}) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			crashed.Store(true)
+			taskCancel()
+		}
+	})
+	return crashed.Load
+}
+
+// isCrashError reports whether a chromedp error means the tab or browser went away
+func isCrashError(err error) bool {
+	return errors.Is(err, chromedp.ErrChannelClosed) || errors.Is(err, chromedp.ErrInvalidContext)
+}
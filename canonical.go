@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// checkCanonical warns when the page has no canonical link, more than one, or one on
+// another host. Payloads: page URL, then the canonical URLs (multiple, external).
+func checkCanonical(canonicalURLs []string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if len(canonicalURLs) == 0 {
+		warnings[WarningCanonicalMissing] = []string{pageURL}
+		return warnings
+	}
+
+	if len(canonicalURLs) > 1 {
+		warnings[WarningCanonicalMultiple] = append([]string{pageURL}, canonicalURLs...)
+	}
+
+	// Search engines use the first one
+	parsedPage, err := url.Parse(pageURL)
+	if err != nil {
+		return warnings
+	}
+	parsedCanonical, err := url.Parse(canonicalURLs[0])
+	if err == nil && parsedCanonical.Host != parsedPage.Host {
+		warnings[WarningCanonicalExternal] = []string{pageURL, canonicalURLs[0]}
+	}
+
+	return warnings
+}
+
+// canonicalTarget is what requesting a canonical URL without following redirects returned
+type canonicalTarget struct {
+	status   int
+	location string // redirect target, only set for 3xx
+	err      string
+}
+
+// Many pages share a canonical, so each target is only requested once per LINK_CACHE_TTL
+var canonicalTargetCache = newTTLCache[canonicalTarget](linkCacheTTL())
+
+// checkCanonicalTarget warns when the canonical URL redirects or doesn't return a 2xx.
+// Payloads: page URL, canonical URL, then the redirect target or the status/error.
+func checkCanonicalTarget(canonicalURL string, pageURL string, insecureSkipVerify bool) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if canonicalURL == "" {
+		return warnings
+	}
+
+	target, checked := canonicalTargetCache.Get(canonicalURL)
+	if !checked {
+		target = fetchCanonicalTarget(canonicalURL, insecureSkipVerify)
+		canonicalTargetCache.Set(canonicalURL, target)
+	}
+
+	switch {
+	case target.err != "":
+		warnings[WarningCanonicalBroken] = []string{pageURL, canonicalURL, target.err}
+	case target.status >= 300 && target.status < 400:
+		warnings[WarningCanonicalRedirects] = []string{pageURL, canonicalURL, target.location}
+	case target.status >= 400:
+		warnings[WarningCanonicalBroken] = []string{pageURL, canonicalURL, fmt.Sprintf("%d", target.status)}
+	}
+
+	return warnings
+}
+
+// fetchCanonicalTarget requests the canonical URL with HEAD (GET for servers without HEAD)
+// and stops at the first response so redirects can be reported
+func fetchCanonicalTarget(canonicalURL string, insecureSkipVerify bool) canonicalTarget {
+	client := *linkClientFor(canonicalURL, insecureSkipVerify)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	var resp *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, canonicalURL, nil)
+		if err != nil {
+			return canonicalTarget{err: err.Error()}
+		}
+		if method == http.MethodGet {
+			req.Header.Set("Range", "bytes=0-0")
+		}
+
+		resp, err = client.Do(req)
+		if err != nil {
+			return canonicalTarget{err: err.Error()}
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	target := canonicalTarget{status: resp.StatusCode}
+	if location, err := resp.Location(); err == nil {
+		target.location = location.String()
+	}
+	return target
+}
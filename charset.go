@@ -0,0 +1,18 @@
+package main
+
+import (
+	"io"
+
+	"golang.org/x/net/html/charset"
+)
+
+// utf8Reader transcodes a raw HTTP body to UTF-8. The encoding comes from the
+// Content-Type header, a BOM or a <meta charset> in the first 1024 bytes, e.g. for
+// Shift-JIS or ISO-8859-1 pages. Unknown encodings are read as they are.
+func utf8Reader(body io.Reader, contentType string) io.Reader {
+	utf8Body, err := charset.NewReader(body, contentType)
+	if err != nil {
+		return body
+	}
+	return utf8Body
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUTF8ReaderShiftJIS(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/shift_jis.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{"charset in Content-Type", "text/html; charset=Shift_JIS"},
+		{"charset in meta tag", "text/html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := io.ReadAll(utf8Reader(bytes.NewReader(fixture), tt.contentType))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{"東京のピザ屋", "本格ナポリピザ", "毎日営業しています。"} {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("transcoded body doesn't contain %q:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchSitemapURLsShiftJIS(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/sitemap_shift_jis.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(bytes.ReplaceAll(fixture, []byte("{{BASE}}"), []byte(server.URL)))
+	}))
+	defer server.Close()
+
+	got, err := fetchSitemapURLs(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{server.URL + "/メニュー", server.URL + "/店舗情報"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFetchRobotsShiftJIS(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/robots_shift_jis.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=Shift_JIS")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	robots := fetchRobots(server.URL)
+	if robots.Allowed(server.URL + "/会員/login") {
+		t.Error("/会員/login is allowed, want it disallowed")
+	}
+	if !robots.Allowed(server.URL + "/メニュー") {
+		t.Error("/メニュー is disallowed, want it allowed")
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MinContentSimilarity is the similarity below which the content counts as changed
+const MinContentSimilarity = 0.9
+
+// CompareRequest compares two versions of a page, e.g. staging and production
+type CompareRequest struct {
+	URLA string `json:"url_a"`
+	URLB string `json:"url_b"`
+}
+
+func (r *CompareRequest) Validate() error {
+	if r.URLA == "" || r.URLB == "" {
+		return errors.New("url_a and url_b are required")
+	}
+	for _, rawURL := range []string{r.URLA, r.URLB} {
+		if err := validatePageURL(rawURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TextDiff holds a value on both pages
+type TextDiff struct {
+	A       string `json:"a"`
+	B       string `json:"b"`
+	Changed bool   `json:"changed"`
+}
+
+// ListDiff holds a list on both pages
+type ListDiff struct {
+	A       []string `json:"a"`
+	B       []string `json:"b"`
+	Changed bool     `json:"changed"`
+}
+
+// CompareResponse is the diff of two pages. Changed lists the SEO-critical elements
+// that differ (title, description, h1, links, content) so a launch can be gated on it.
+type CompareResponse struct {
+	URLA        string   `json:"url_a"`
+	URLB        string   `json:"url_b"`
+	Changed     []string `json:"changed"`
+	Title       TextDiff `json:"title"`
+	Description TextDiff `json:"description"`
+	H1s         ListDiff `json:"h1s"`
+	WordsA      int      `json:"words_a"`
+	WordsB      int      `json:"words_b"`
+	WordsDelta  int      `json:"words_delta"`
+	// Links are compared by path on the page's own host, so staging and production
+	// links match, and by full URL elsewhere
+	LinksMissing []string `json:"links_missing"` // on A, not on B
+	LinksAdded   []string `json:"links_added"`   // on B, not on A
+	// Similarity is the share of word shingles both pages have, 1 for identical text
+	Similarity float64 `json:"similarity"`
+}
+
+// comparePages diffs two extracted pages
+func comparePages(a ExtractResult, b ExtractResult) CompareResponse {
+	h1sA, h1sB := extractedH1s(a.Headings), extractedH1s(b.Headings)
+	linksA, linksB := comparableLinks(a), comparableLinks(b)
+
+	result := CompareResponse{
+		URLA:         a.Url,
+		URLB:         b.Url,
+		Changed:      []string{},
+		Title:        TextDiff{A: a.Title, B: b.Title, Changed: a.Title != b.Title},
+		Description:  TextDiff{A: a.Description, B: b.Description, Changed: a.Description != b.Description},
+		H1s:          ListDiff{A: h1sA, B: h1sB, Changed: !slices.Equal(h1sA, h1sB)},
+		WordsA:       a.Words,
+		WordsB:       b.Words,
+		WordsDelta:   b.Words - a.Words,
+		LinksMissing: missingFrom(linksA, linksB),
+		LinksAdded:   missingFrom(linksB, linksA),
+		Similarity:   textSimilarity(a.text, b.text),
+	}
+
+	if result.Title.Changed {
+		result.Changed = append(result.Changed, "title")
+	}
+	if result.Description.Changed {
+		result.Changed = append(result.Changed, "description")
+	}
+	if result.H1s.Changed {
+		result.Changed = append(result.Changed, "h1")
+	}
+	if len(result.LinksMissing) > 0 || len(result.LinksAdded) > 0 {
+		result.Changed = append(result.Changed, "links")
+	}
+	if result.Similarity < MinContentSimilarity {
+		result.Changed = append(result.Changed, "content")
+	}
+
+	return result
+}
+
+// extractedH1s returns the texts of the H1 headings in document order
+func extractedH1s(headings []ExtractedHeading) []string {
+	texts := []string{}
+	for _, heading := range headings {
+		if heading.Level == 1 {
+			texts = append(texts, heading.Text)
+		}
+	}
+	return texts
+}
+
+// comparableLinks returns the distinct links of a page, same-host ones reduced to path and query
+func comparableLinks(page ExtractResult) []string {
+	pageURL, err := url.Parse(page.Url)
+	if err != nil {
+		return []string{}
+	}
+
+	links := []string{}
+	for _, link := range page.Links {
+		parsed, err := url.Parse(link.Href)
+		if err != nil {
+			continue
+		}
+		parsed.Fragment = ""
+
+		key := parsed.String()
+		if parsed.Host == pageURL.Host {
+			key = parsed.RequestURI()
+		}
+		if !slices.Contains(links, key) {
+			links = append(links, key)
+		}
+	}
+	return links
+}
+
+// missingFrom returns the items of a that aren't in b
+func missingFrom(a []string, b []string) []string {
+	missing := []string{}
+	for _, item := range a {
+		if !slices.Contains(b, item) {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
+
+// textSimilarity is the Jaccard similarity of the 3-word shingles of both texts
+func textSimilarity(a string, b string) float64 {
+	shinglesA, shinglesB := shingles(a), shingles(b)
+	if len(shinglesA) == 0 && len(shinglesB) == 0 {
+		return 1
+	}
+
+	shared := 0
+	for shingle := range shinglesA {
+		if shinglesB[shingle] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(shinglesA)+len(shinglesB)-shared)
+}
+
+// shingles returns the set of lowercased 3-word sequences, or the text itself when it's shorter
+func shingles(text string) map[string]bool {
+	words := strings.Fields(strings.ToLower(text))
+	set := make(map[string]bool)
+	if len(words) > 0 && len(words) < 3 {
+		set[strings.Join(words, " ")] = true
+	}
+	for i := 0; i+3 <= len(words); i++ {
+		set[strings.Join(words[i:i+3], " ")] = true
+	}
+	return set
+}
+
+// compareHandler extracts both pages in parallel and returns what changed between them
+func compareHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validAPIKey(r) {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	var req CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var a, b ExtractResult
+	var wg sync.WaitGroup
+	wg.Go(func() { a = ExtractPage(req.URLA, scrapeBrowser.Context()) })
+	wg.Go(func() { b = ExtractPage(req.URLB, scrapeBrowser.Context()) })
+	wg.Wait()
+
+	for _, page := range []ExtractResult{a, b} {
+		if page.Error != "" {
+			http.Error(w, fmt.Sprintf("failed to load %s: %s", page.Url, page.Error), http.StatusBadGateway)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparePages(a, b))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// contentTypeWorkers bounds the HEAD requests filterHTMLPages sends at once
+const contentTypeWorkers = 8
+
+// contentTypeCache maps URLs to their media type for LINK_CACHE_TTL
+var contentTypeCache = newTTLCache[string](linkCacheTTL())
+
+// fetchContentType returns the media type a HEAD request reports for link, cached per URL.
+// Returns "" when the server doesn't say or the request fails.
+func fetchContentType(link string, insecureSkipVerify bool) string {
+	mediaType, ok := contentTypeCache.Get(link)
+	if ok {
+		return mediaType
+	}
+
+	req, err := http.NewRequest(http.MethodHead, link, nil)
+	if err != nil {
+		return ""
+	}
+
+	resp, err := linkClientFor(link, insecureSkipVerify).Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode < 400 {
+			mediaType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		}
+	}
+
+	contentTypeCache.Set(link, mediaType)
+
+	return mediaType
+}
+
+// isHTMLPage reports whether link looks like an HTML page worth opening in Chrome.
+// Unknown content types are treated as pages so Chrome gets the final say.
+func isHTMLPage(link string, insecureSkipVerify bool) bool {
+	switch fetchContentType(link, insecureSkipVerify) {
+	case "", "text/html", "application/xhtml+xml":
+		return true
+	default:
+		return false
+	}
+}
+
+// filterHTMLPages returns the links isHTMLPage accepts, in their order. The HEAD requests
+// run concurrently so a batch of slow servers doesn't hold up the crawl 5s per link.
+func filterHTMLPages(links []string, insecureSkipVerify bool) []string {
+	isPage := make([]bool, len(links))
+	sem := make(chan struct{}, contentTypeWorkers)
+	var wg sync.WaitGroup
+	for i, link := range links {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			isPage[i] = isHTMLPage(link, insecureSkipVerify)
+		})
+	}
+	wg.Wait()
+
+	pages := make([]string, 0, len(links))
+	for i, link := range links {
+		if isPage[i] {
+			pages = append(pages, link)
+		}
+	}
+	return pages
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterHTMLPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow enough that checking the links one by one would take over a second
+		time.Sleep(200 * time.Millisecond)
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/menu"):
+			w.Header().Set("Content-Type", "application/pdf")
+		case strings.HasPrefix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+	}))
+	defer server.Close()
+
+	links := []string{
+		server.URL + "/about",
+		server.URL + "/menu",
+		server.URL + "/contact",
+		server.URL + "/menu-2024",
+		server.URL + "/missing",
+		server.URL + "/blog",
+		server.URL + "/blog/pizza",
+		server.URL + "/jobs",
+	}
+
+	start := time.Now()
+	got := filterHTMLPages(links, false)
+	elapsed := time.Since(start)
+
+	// Unknown content types, like the 404's, are left for Chrome to decide
+	want := []string{
+		server.URL + "/about",
+		server.URL + "/contact",
+		server.URL + "/missing",
+		server.URL + "/blog",
+		server.URL + "/blog/pizza",
+		server.URL + "/jobs",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if elapsed > time.Second {
+		t.Errorf("took %s, the HEAD requests don't run concurrently", elapsed)
+	}
+}
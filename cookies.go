@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/net/http/httpguts"
+)
+
+// MaxCookies bounds the cookies of a request
+const MaxCookies = 50
+
+// Cookie is set in the tab before the page loads, e.g. a session cookie to see the
+// logged-in version of a page. Chrome only sends it to Domain, like any other cookie.
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+	// Path defaults to "/"
+	Path string `json:"path"`
+}
+
+func (c *Cookie) Validate() error {
+	if c.Name == "" || c.Domain == "" {
+		return errors.New("cookies need a name and a domain")
+	}
+	if !httpguts.ValidHeaderFieldName(c.Name) {
+		return fmt.Errorf("invalid cookie name %q", c.Name)
+	}
+	return nil
+}
+
+// validateCookies checks the cookies request field, nil is fine. Malformed cookies
+// don't fail the request, setCookies skips them.
+func validateCookies(cookies []Cookie) error {
+	if len(cookies) > MaxCookies {
+		return fmt.Errorf("at most %d cookies are allowed", MaxCookies)
+	}
+	return nil
+}
+
+// setCookies stores the cookies in the tab's browser context. Malformed cookies and
+// ones Chrome refuses, e.g. for a malformed domain, are logged and skipped so the page
+// still loads. Must run after network.Enable and before navigating.
+func setCookies(cookies []Cookie, logger *slog.Logger) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, cookie := range cookies {
+			if err := cookie.Validate(); err != nil {
+				logger.Warn("skipping cookie", "name", cookie.Name, "domain", cookie.Domain, "error", err)
+				continue
+			}
+			path := cookie.Path
+			if path == "" {
+				path = "/"
+			}
+			err := network.SetCookie(cookie.Name, cookie.Value).
+				WithDomain(cookie.Domain).
+				WithPath(path).
+				Do(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				logger.Warn("skipping cookie", "name", cookie.Name, "domain", cookie.Domain, "error", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestValidateCookies(t *testing.T) {
+	// A malformed cookie is skipped when the tab is set up, the request still runs
+	cookies := []Cookie{
+		{Name: "session", Value: "abc123", Domain: "example.com"},
+		{Name: "", Value: "orphan", Domain: "example.com"},
+		{Name: "bad name", Value: "x", Domain: "example.com"},
+		{Name: "lang", Value: "de"},
+	}
+	if err := validateCookies(cookies); err != nil {
+		t.Errorf("validateCookies with malformed cookies: %v, want nil", err)
+	}
+
+	if err := validateCookies(make([]Cookie, MaxCookies+1)); err == nil {
+		t.Errorf("validateCookies with %d cookies: nil, want an error", MaxCookies+1)
+	}
+}
+
+func TestCookieValidate(t *testing.T) {
+	tests := []struct {
+		cookie  Cookie
+		wantErr bool
+	}{
+		{Cookie{Name: "session", Value: "abc123", Domain: "example.com"}, false},
+		{Cookie{Name: "session", Value: "abc123"}, true},
+		{Cookie{Value: "abc123", Domain: "example.com"}, true},
+		{Cookie{Name: "bad name", Domain: "example.com"}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.cookie.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() = %v, want error %t", tt.cookie, err, tt.wantErr)
+		}
+	}
+}
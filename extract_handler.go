@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type ExtractResponse struct {
+	Results []ExtractResult `json:"results"`
+}
+
+// ExtractRequest structure
+type ExtractRequest struct {
+	URLs []string `json:"urls"`
+}
+
+func (r *ExtractRequest) Validate() error {
+	if len(r.URLs) == 0 {
+		return errors.New("no target urls provided")
+	}
+	for _, rawURL := range r.URLs {
+		if err := validatePageURL(rawURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractHandler returns the raw extracted page data with no checks applied
+func extractHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	MAX_TABS := 2
+	if os.Getenv("AUDIT_TABS") != "" {
+		num, err := strconv.Atoi(os.Getenv("AUDIT_TABS"))
+		if err == nil {
+			MAX_TABS = num
+		}
+	}
+
+	if !validAPIKey(r) {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	var req ExtractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	err := req.Validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateReportCompress(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resultsChannel := make(chan ExtractResult)
+	var wg sync.WaitGroup
+
+	for _, urls := range divideUrls(req.URLs, MAX_TABS) {
+		wg.Go(func() {
+			for _, url := range urls {
+				select {
+				case <-r.Context().Done():
+					return
+				default:
+				}
+
+				// Pages open as tabs of the shared browser instead of a Chrome per request
+				result := ExtractPage(url, withSpan(scrapeBrowser.Context(), r.Context()))
+				if result.Error != "" {
+					observePage("extract", Tenant{}, PageOutcomeError)
+				} else {
+					observePage("extract", Tenant{}, PageOutcomeSuccess)
+				}
+				resultsChannel <- result
+			}
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsChannel)
+	}()
+
+	output := make([]ExtractResult, 0, len(req.URLs))
+	for result := range resultsChannel {
+		output = append(output, result)
+	}
+
+	writeJSONReport(w, r, "extract", ExtractResponse{Results: output})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// ExtractedHeading is a heading element in document order
+type ExtractedHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+// ExtractedLink is an a[href] element
+type ExtractedLink struct {
+	Href string `json:"href"`
+	Rel  string `json:"rel"`
+	Text string `json:"text"`
+}
+
+// ExtractedImage is an img element, dimensions come from attributes since images aren't loaded
+type ExtractedImage struct {
+	Src    string `json:"src"`
+	Alt    string `json:"alt"`
+	HasAlt bool   `json:"has_alt"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ExtractResult contains the raw data the audit checks work on, without any warnings
+type ExtractResult struct {
+	Url         string             `json:"url"`
+	StatusCode  int                `json:"status_code"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Headings    []ExtractedHeading `json:"headings"`
+	Links       []ExtractedLink    `json:"links"`
+	Images      []ExtractedImage   `json:"images"`
+	Words       int                `json:"words"`
+	Error       string             `json:"error,omitempty"`
+
+	// text is the visible body text, kept for comparisons but not returned by /extract
+	text string
+}
+
+// pageData is filled by a single evaluate in ExtractPage
+type pageData struct {
+	Description string             `json:"description"`
+	Headings    []ExtractedHeading `json:"headings"`
+	Links       []ExtractedLink    `json:"links"`
+	Images      []ExtractedImage   `json:"images"`
+}
+
+// ExtractPage loads a page and returns its raw extracted data
+func ExtractPage(pageURL string, parentCtx context.Context) ExtractResult {
+	if err := tabPool.Acquire(parentCtx); err != nil {
+		return ExtractResult{
+			Url:      pageURL,
+			Headings: []ExtractedHeading{},
+			Links:    []ExtractedLink{},
+			Images:   []ExtractedImage{},
+			Error:    err.Error(),
+		}
+	}
+	defer tabPool.Release()
+
+	// Context with timeout for this specific page
+	ctx, cancel := context.WithTimeout(parentCtx, PageTimeout)
+	defer cancel()
+
+	// Create a new browser context from the shared allocator
+	taskCtx, taskCancel := chromedp.NewContext(ctx)
+	defer taskCancel()
+
+	statusCode := listenDocumentStatus(taskCtx)
+
+	var title string
+	var pageText string
+	var data pageData
+
+	err := chromedp.Run(taskCtx,
+		network.Enable(),
+		chromedp.Navigate(pageURL),
+		chromedp.Poll(`document.readyState === "complete"`, nil),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.Sleep(500*time.Millisecond),
+
+		chromedp.Text("body", &pageText, chromedp.NodeVisible, chromedp.ByQuery),
+		chromedp.Title(&title),
+		chromedp.EvaluateAsDevTools(`({
+			description: (document.querySelector('meta[name="description"]') || {}).content || "",
+			headings: Array.from(document.querySelectorAll("h1,h2,h3,h4,h5,h6"))
+				.map(el => ({level: Number(el.tagName[1]), text: el.innerText.trim()})),
+			links: Array.from(document.querySelectorAll("a[href]"))
+				.map(el => ({href: el.href, rel: el.rel || "", text: el.innerText.trim()})),
+			images: Array.from(document.querySelectorAll("img"))
+				.map(el => ({
+					src: el.src,
+					alt: el.getAttribute("alt") || "",
+					has_alt: el.hasAttribute("alt"),
+					width: parseInt(el.getAttribute("width")) || 0,
+					height: parseInt(el.getAttribute("height")) || 0,
+				})),
+		})`, &data),
+	)
+	if err != nil {
+		return ExtractResult{
+			Url:        pageURL,
+			StatusCode: statusCode(),
+			Headings:   []ExtractedHeading{},
+			Links:      []ExtractedLink{},
+			Images:     []ExtractedImage{},
+			Error:      err.Error(),
+		}
+	}
+
+	return ExtractResult{
+		Url:         pageURL,
+		StatusCode:  statusCode(),
+		Title:       title,
+		Description: data.Description,
+		Headings:    data.Headings,
+		Links:       data.Links,
+		Images:      data.Images,
+		Words:       len(strings.Fields(pageText)),
+		text:        pageText,
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// GCSExportTimeout bounds the upload of a report
+const GCSExportTimeout = 2 * time.Minute
+
+// gcsBucket is where reports are exported to, set with GCS_BUCKET
+func gcsBucket() string {
+	return os.Getenv("GCS_BUCKET")
+}
+
+// validateGCSExport fails requests asking for an export when no bucket is configured
+func validateGCSExport(exportGCS bool) error {
+	if exportGCS && gcsBucket() == "" {
+		return errors.New("export_gcs requires GCS_BUCKET to be set")
+	}
+	return nil
+}
+
+// exportToGCS writes report as gzipped JSON to GCS_BUCKET under
+// audits/<taskId>-<timestamp>.json.gz and returns the object URL. The tenant
+// is stored as object metadata so downstream consumers can route on it.
+func exportToGCS(ctx context.Context, taskId string, tenant Tenant, report any) (string, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to create storage client: %w", err)
+	}
+	defer client.Close()
+
+	bucket := gcsBucket()
+	objectName := fmt.Sprintf("audits/%s-%s.json.gz", taskId, time.Now().UTC().Format("20060102T150405Z"))
+
+	w := client.Bucket(bucket).Object(objectName).NewWriter(ctx)
+	w.ContentType = "application/json"
+	w.ContentEncoding = "gzip"
+	w.Metadata = tenant.Attributes()
+
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(report); err != nil {
+		w.Close()
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		w.Close()
+		return "", fmt.Errorf("failed to compress report: %w", err)
+	}
+	// The object only exists once the writer is closed
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to upload report: %w", err)
+	}
+
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucket, objectName), nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// HAR is an HTTP Archive 1.2 document, see http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"` // total milliseconds, -1 when the request never finished
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"` // loading error, if any
+}
+
+type HARRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []struct{}  `json:"cookies"`
+	Headers     []HARHeader `json:"headers"`
+	QueryString []HARHeader `json:"queryString"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int64       `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []struct{}  `json:"cookies"`
+	Headers     []HARHeader `json:"headers"`
+	Content     HARContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type HARHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harEntry is an entry being recorded, keyed by request ID until the page is done
+type harEntry struct {
+	entry   HAREntry
+	started time.Time // monotonic timestamp of the request
+}
+
+// finish sets the total time. CDP doesn't split it up without extra timing data,
+// so it is all reported as wait.
+func (e *harEntry) finish(at time.Time) {
+	elapsed := float64(at.Sub(e.started).Microseconds()) / 1000
+	e.entry.Time = elapsed
+	e.entry.Timings.Wait = elapsed
+}
+
+// recordHar records the network activity of the tab. Must be called before chromedp.Run
+// with network.Enable in the actions, the returned func builds the HAR from what was seen.
+func recordHar(taskCtx context.Context) func() *HAR {
+	var mu sync.Mutex
+	pending := make(map[network.RequestID]*harEntry)
+	var done []*harEntry
+
+	chromedp.ListenTarget(taskCtx, func(ev interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch e := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			// Redirects reuse the request ID, the previous hop ends with the redirect response
+			if previous, ok := pending[e.RequestID]; ok && e.RedirectResponse != nil {
+				previous.entry.Response = harResponse(e.RedirectResponse)
+				previous.finish(e.Timestamp.Time())
+				done = append(done, previous)
+			}
+			pending[e.RequestID] = &harEntry{
+				entry: HAREntry{
+					StartedDateTime: e.WallTime.Time().Format(time.RFC3339Nano),
+					Time:            -1,
+					Request: HARRequest{
+						Method:      e.Request.Method,
+						URL:         e.Request.URL,
+						Cookies:     []struct{}{},
+						Headers:     harHeaders(e.Request.Headers),
+						QueryString: []HARHeader{},
+						HeadersSize: -1,
+						BodySize:    -1,
+					},
+					Response: HARResponse{
+						Cookies:     []struct{}{},
+						Headers:     []HARHeader{},
+						HeadersSize: -1,
+						BodySize:    -1,
+					},
+				},
+				started: e.Timestamp.Time(),
+			}
+		case *network.EventResponseReceived:
+			if pendingEntry, ok := pending[e.RequestID]; ok {
+				pendingEntry.entry.Response = harResponse(e.Response)
+				pendingEntry.entry.Request.HTTPVersion = pendingEntry.entry.Response.HTTPVersion
+			}
+		case *network.EventLoadingFinished:
+			if pendingEntry, ok := pending[e.RequestID]; ok {
+				pendingEntry.finish(e.Timestamp.Time())
+				pendingEntry.entry.Response.BodySize = int(e.EncodedDataLength)
+				pendingEntry.entry.Response.Content.Size = int(e.EncodedDataLength)
+				done = append(done, pendingEntry)
+				delete(pending, e.RequestID)
+			}
+		case *network.EventLoadingFailed:
+			if pendingEntry, ok := pending[e.RequestID]; ok {
+				pendingEntry.finish(e.Timestamp.Time())
+				pendingEntry.entry.Comment = e.ErrorText
+				done = append(done, pendingEntry)
+				delete(pending, e.RequestID)
+			}
+		}
+	})
+
+	return func() *HAR {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Requests still in flight when the page was read are included unfinished
+		all := append([]*harEntry{}, done...)
+		for _, pendingEntry := range pending {
+			all = append(all, pendingEntry)
+		}
+		sort.Slice(all, func(i, j int) bool {
+			return all[i].started.Before(all[j].started)
+		})
+
+		entries := make([]HAREntry, 0, len(all))
+		for _, recorded := range all {
+			entries = append(entries, recorded.entry)
+		}
+		return &HAR{Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "go-scraper", Version: "1.0"},
+			Entries: entries,
+		}}
+	}
+}
+
+// harResponse converts a CDP response, the body size is filled in once loading finishes
+func harResponse(r *network.Response) HARResponse {
+	response := HARResponse{
+		Status:      r.Status,
+		StatusText:  r.StatusText,
+		HTTPVersion: r.Protocol,
+		Cookies:     []struct{}{},
+		Headers:     harHeaders(r.Headers),
+		Content:     HARContent{MimeType: r.MimeType},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	if location, ok := r.Headers["Location"]; ok {
+		response.RedirectURL = fmt.Sprint(location)
+	} else if location, ok := r.Headers["location"]; ok {
+		response.RedirectURL = fmt.Sprint(location)
+	}
+	return response
+}
+
+// harRedactedHeaders carry credentials, e.g. from the request's headers and cookies. HARs
+// are kept in the job store and exported to GCS, so their values are never recorded.
+var harRedactedHeaders = []string{"authorization", "proxy-authorization", "cookie", "set-cookie"}
+
+// harHeaders flattens CDP headers into HAR name/value pairs, see harRedactedHeaders
+func harHeaders(headers network.Headers) []HARHeader {
+	harHeaders := make([]HARHeader, 0, len(headers))
+	for name, value := range headers {
+		if slices.Contains(harRedactedHeaders, strings.ToLower(name)) {
+			value = "[redacted]"
+		}
+		harHeaders = append(harHeaders, HARHeader{Name: name, Value: fmt.Sprint(value)})
+	}
+	sort.Slice(harHeaders, func(i, j int) bool {
+		return harHeaders[i].Name < harHeaders[j].Name
+	})
+	return harHeaders
+}
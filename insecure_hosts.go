@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// SECURITY: InsecureSkipVerify turns off TLS certificate verification for both
+// Chrome and the link checker. That means an expired, self-signed or spoofed
+// certificate is silently accepted, and the audit can no longer tell whether a
+// site's HTTPS setup is broken. It exists only so internal/staging sites with
+// self-signed certs can be audited, and is therefore refused unless every
+// target host is a private/loopback address or is listed in the
+// INSECURE_ALLOWED_HOSTS env var (comma separated host names).
+
+// checkInsecureAllowed returns an error if any of the urls may not be audited with InsecureSkipVerify
+func checkInsecureAllowed(urls []string) error {
+	for _, rawURL := range urls {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+		}
+		if !isInsecureAllowedHost(parsed.Hostname()) {
+			return fmt.Errorf("insecure_skip_verify is only allowed for private or allowlisted hosts, got %q", parsed.Hostname())
+		}
+	}
+	return nil
+}
+
+// isInsecureAllowedHost reports whether certificate checks may be skipped for host
+func isInsecureAllowedHost(host string) bool {
+	if host == "" {
+		return false
+	}
+
+	allowed := strings.Split(os.Getenv("INSECURE_ALLOWED_HOSTS"), ",")
+	if slices.ContainsFunc(allowed, func(h string) bool {
+		return strings.EqualFold(strings.TrimSpace(h), host)
+	}) {
+		return true
+	}
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	// Every address the host resolves to must be private, otherwise a public
+	// name could be pointed at us and skip verification
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		addrs, err := net.LookupIP(host)
+		if err != nil || len(addrs) == 0 {
+			return false
+		}
+		ips = addrs
+	}
+	for _, ip := range ips {
+		if !ip.IsPrivate() && !ip.IsLoopback() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// disableJavaScript stops page scripts from running, must run before chromedp.Navigate.
+// DevTools evaluations still work so extraction is unaffected.
+func disableJavaScript() chromedp.Action {
+	return emulation.SetScriptExecutionDisabled(true)
+}
+
+// noJSSnapshot loads p's page in a new tab with JavaScript disabled and returns
+// its word count and links, i.e. what a crawler that doesn't render JS sees. The tab
+// takes a slot of its own and is set up like the audited one, so it's paced and sends
+// the same referer, headers and cookies.
+func noJSSnapshot(ctx context.Context, p AuditPageParams) (words int, links []string, err error) {
+	if err := p.Pacer.Wait(ctx, p.PageURL); err != nil {
+		return 0, nil, err
+	}
+	if err := tabPool.Acquire(ctx); err != nil {
+		return 0, nil, err
+	}
+	defer tabPool.Release()
+
+	taskCtx, taskCancel := chromedp.NewContext(ctx)
+	defer taskCancel()
+
+	actions := []chromedp.Action{
+		network.Enable(),
+		disableJavaScript(),
+		setRequestHeaders(p.Referer, p.UserAgent, p.Headers),
+	}
+	if len(p.Cookies) > 0 {
+		actions = append(actions, setCookies(p.Cookies, p.Tenant.Logger()))
+	}
+
+	var pageText string
+	actions = append(actions,
+		chromedp.Navigate(p.PageURL),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.Text("body", &pageText, chromedp.ByQuery),
+		chromedp.EvaluateAsDevTools(`
+			Array.from(document.querySelectorAll("a[href]"))
+			     .map(el => el.href)
+		`, &links),
+	)
+	if err := chromedp.Run(taskCtx, actions...); err != nil {
+		return 0, nil, err
+	}
+
+	return len(strings.Fields(pageText)), links, nil
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job states
+const (
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+	// JobCancelled jobs were stopped by /audit/cancel, their result has the pages audited until then
+	JobCancelled JobStatus = "cancelled"
+)
+
+type JobStatus string
+
+// Defaults for the job store, override with MAX_FINISHED_JOBS and JOB_TTL (e.g. "6h")
+const (
+	DefaultMaxFinishedJobs = 100
+	DefaultJobTTL          = 24 * time.Hour
+)
+
+// Job is an audit run in the background
+type Job struct {
+	ID         string
+	Status     JobStatus
+	Tenant     Tenant
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	Error      string
+	// PagesProcessed counts the pages audited so far
+	PagesProcessed int
+	// IdempotencyKey is the Idempotency-Key header the job was started with, if any
+	IdempotencyKey string
+
+	// result is the gzipped JSON AuditResult, a few MB of warnings compress very well
+	result []byte
+	// cancel stops the running audit, set by the audit once it started
+	cancel          func()
+	cancelRequested bool
+}
+
+// Result decodes the stored AuditResult, nil while the job is running or when it failed
+func (j *Job) Result() (*AuditResult, error) {
+	if j.result == nil {
+		return nil, nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(j.result))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var result AuditResult
+	if err := json.NewDecoder(gz).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// JobStore keeps jobs in memory. Running jobs are always kept, finished ones expire
+// after ttl and only the newest maxFinished are retained.
+type JobStore struct {
+	jobs        map[string]*Job
+	byKey       map[string]string // tenant-scoped idempotency key -> job ID
+	maxFinished int
+	ttl         time.Duration
+	mu          sync.Mutex
+	// audits counts the background audits started with Go, for Shutdown
+	audits sync.WaitGroup
+}
+
+func NewJobStore(maxFinished int, ttl time.Duration) *JobStore {
+	return &JobStore{
+		jobs:        make(map[string]*Job),
+		byKey:       make(map[string]string),
+		maxFinished: maxFinished,
+		ttl:         ttl,
+	}
+}
+
+// jobStore holds the jobs of the async audit API
+var jobStore = NewJobStore(maxFinishedJobs(), jobTTL())
+
+// maxFinishedJobs reads MAX_FINISHED_JOBS, falling back to DefaultMaxFinishedJobs
+func maxFinishedJobs() int {
+	num, err := strconv.Atoi(os.Getenv("MAX_FINISHED_JOBS"))
+	if err != nil || num <= 0 {
+		return DefaultMaxFinishedJobs
+	}
+	return num
+}
+
+// jobTTL reads JOB_TTL, falling back to DefaultJobTTL
+func jobTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("JOB_TTL"))
+	if err != nil || ttl <= 0 {
+		return DefaultJobTTL
+	}
+	return ttl
+}
+
+// Create registers a new running job with a random ID
+func (s *JobStore) Create(tenant Tenant) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.create(tenant, "")
+}
+
+// CreateIdempotent is Create for requests with an Idempotency-Key. If the tenant already
+// started a job with that key which is still running or kept as finished, a copy of it is
+// returned with created false instead, so a retried request doesn't start a second audit.
+func (s *JobStore) CreateIdempotent(tenant Tenant, key string) (job Job, created bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evict()
+	if id, ok := s.byKey[scopedIdempotencyKey(tenant, key)]; ok {
+		if existing, ok := s.jobs[id]; ok {
+			return *existing, false
+		}
+	}
+	return *s.create(tenant, key), true
+}
+
+// create adds a running job. Must hold mu.
+func (s *JobStore) create(tenant Tenant, idempotencyKey string) *Job {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	job := &Job{
+		ID:             hex.EncodeToString(id),
+		Status:         JobRunning,
+		Tenant:         tenant,
+		CreatedAt:      time.Now(),
+		IdempotencyKey: idempotencyKey,
+	}
+
+	s.jobs[job.ID] = job
+	if idempotencyKey != "" {
+		s.byKey[scopedIdempotencyKey(tenant, idempotencyKey)] = job.ID
+	}
+	return job
+}
+
+// scopedIdempotencyKey keeps tenants from colliding on the same key
+func scopedIdempotencyKey(tenant Tenant, key string) string {
+	return tenant.ProjectID + "/" + tenant.ClientID + "/" + key
+}
+
+// Get returns a copy of the job, false if it doesn't exist or was evicted
+func (s *JobStore) Get(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evict()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// SetProgress updates the number of pages a running job has audited, jobs that don't
+// exist (audits not started through the async API) are ignored
+func (s *JobStore) SetProgress(id string, pagesProcessed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[id]; ok && job.Status == JobRunning {
+		job.PagesProcessed = pagesProcessed
+	}
+}
+
+// SetCancel registers how to stop a running job. If it was cancelled before the audit
+// got this far, cancel is called right away.
+func (s *JobStore) SetCancel(id string, cancel func()) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	job.cancel = cancel
+	cancelRequested := job.cancelRequested
+	s.mu.Unlock()
+
+	if cancelRequested {
+		cancel()
+	}
+}
+
+// Cancel asks a running job to stop and returns its status, false if it doesn't exist.
+// The cancel func runs outside the lock so a slow one can't hold up the store.
+func (s *JobStore) Cancel(id string) (JobStatus, bool) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	if !ok {
+		s.mu.Unlock()
+		return "", false
+	}
+	status := job.Status
+	cancel := job.cancel
+	alreadyRequested := job.cancelRequested
+	if status == JobRunning {
+		job.cancelRequested = true
+	}
+	s.mu.Unlock()
+
+	if status == JobRunning && !alreadyRequested && cancel != nil {
+		cancel()
+	}
+	return status, true
+}
+
+// Go runs a job's audit in the background, Shutdown waits for it
+func (s *JobStore) Go(audit func()) {
+	s.audits.Go(audit)
+}
+
+// Shutdown cancels the running jobs and waits for their audits to return, or for ctx
+// to be done. No jobs may be started once it's called.
+func (s *JobStore) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	running := []string{}
+	for id, job := range s.jobs {
+		if job.Status == JobRunning {
+			running = append(running, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range running {
+		s.Cancel(id)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.audits.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Finish stores the outcome of a job and evicts old jobs
+func (s *JobStore) Finish(id string, result *AuditResult, err error) {
+	var compressed []byte
+	if result != nil {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if encodeErr := json.NewEncoder(gz).Encode(result); encodeErr != nil && err == nil {
+			err = encodeErr
+		}
+		gz.Close()
+		compressed = buf.Bytes()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+	job.FinishedAt = time.Now()
+	job.cancel = nil
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+	} else if job.cancelRequested {
+		job.Status = JobCancelled
+		job.result = compressed
+	} else {
+		job.Status = JobDone
+		job.result = compressed
+	}
+
+	s.evict()
+}
+
+// Counts returns the number of jobs in each state
+func (s *JobStore) Counts() map[JobStatus]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evict()
+	counts := map[JobStatus]int{JobRunning: 0, JobDone: 0, JobFailed: 0, JobCancelled: 0}
+	for _, job := range s.jobs {
+		counts[job.Status]++
+	}
+	return counts
+}
+
+// evict drops expired finished jobs, then the oldest ones over maxFinished. Must hold mu.
+func (s *JobStore) evict() {
+	finished := []*Job{}
+	for _, job := range s.jobs {
+		if job.Status == JobRunning {
+			continue
+		}
+		if time.Since(job.FinishedAt) > s.ttl {
+			s.remove(job)
+			continue
+		}
+		finished = append(finished, job)
+	}
+
+	if len(finished) <= s.maxFinished {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.Before(finished[j].FinishedAt)
+	})
+	for _, job := range finished[:len(finished)-s.maxFinished] {
+		s.remove(job)
+	}
+}
+
+// remove drops a job and its idempotency key. Must hold mu.
+func (s *JobStore) remove(job *Job) {
+	delete(s.jobs, job.ID)
+	if job.IdempotencyKey != "" {
+		delete(s.byKey, scopedIdempotencyKey(job.Tenant, job.IdempotencyKey))
+	}
+}
+
+// MaxIdempotencyKeyLength bounds the Idempotency-Key header
+const MaxIdempotencyKeyLength = 255
+
+// idempotencyKey reads the Idempotency-Key header, empty when the client didn't send one
+func idempotencyKey(r *http.Request) (string, error) {
+	key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if len(key) > MaxIdempotencyKeyLength {
+		return "", fmt.Errorf("Idempotency-Key must be at most %d characters", MaxIdempotencyKeyLength)
+	}
+	return key, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobStoreShutdownCancelsRunningAudits(t *testing.T) {
+	store := NewJobStore(DefaultMaxFinishedJobs, DefaultJobTTL)
+	job := store.Create(Tenant{})
+
+	auditCtx, cancelAudit := context.WithCancel(context.Background())
+	store.SetCancel(job.ID, cancelAudit)
+	store.Go(func() {
+		<-auditCtx.Done()
+		// Stopping takes a moment, like closing the audit's tabs
+		time.Sleep(50 * time.Millisecond)
+		store.Finish(job.ID, &AuditResult{}, nil)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if got, _ := store.Get(job.ID); got.Status != JobCancelled {
+		t.Errorf("got status %s, want %s", got.Status, JobCancelled)
+	}
+}
+
+func TestJobStoreShutdownDeadline(t *testing.T) {
+	store := NewJobStore(DefaultMaxFinishedJobs, DefaultJobTTL)
+	store.Create(Tenant{})
+
+	// An audit that ignores cancellation
+	release := make(chan struct{})
+	defer close(release)
+	store.Go(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := store.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown: %v, want %v", err, context.DeadlineExceeded)
+	}
+}
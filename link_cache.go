@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Link cache scopes accepted in requests
+const (
+	// LinkCacheGlobal shares verdicts between all audits on this server (best hit rate)
+	LinkCacheGlobal = "global"
+	// LinkCacheAudit starts every audit with an empty cache so results are always fresh
+	LinkCacheAudit = "audit"
+)
+
+// DefaultLinkCacheTTL is how long a link verdict is trusted, override with LINK_CACHE_TTL (e.g. "30m")
+const DefaultLinkCacheTTL = 10 * time.Minute
+
+// LinkCache remembers whether links are alive so each one is only checked once per TTL
+type LinkCache = ttlCache[linkCheck]
+
+func NewLinkCache(ttl time.Duration) *LinkCache {
+	return newTTLCache[linkCheck](ttl)
+}
+
+// linkCacheTTL reads LINK_CACHE_TTL, falling back to DefaultLinkCacheTTL
+func linkCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("LINK_CACHE_TTL"))
+	if err != nil || ttl <= 0 {
+		return DefaultLinkCacheTTL
+	}
+	return ttl
+}
+
+// globalLinkCache is shared by every audit using the global scope
+var globalLinkCache = NewLinkCache(linkCacheTTL())
+
+// validateLinkCacheScope returns an error for unknown link cache scopes
+func validateLinkCacheScope(scope string) error {
+	switch scope {
+	case "", LinkCacheGlobal, LinkCacheAudit:
+		return nil
+	default:
+		return fmt.Errorf("invalid link_cache_scope %q, expected %q or %q", scope, LinkCacheGlobal, LinkCacheAudit)
+	}
+}
+
+// linkCacheForScope returns the cache an audit with the given scope should use
+func linkCacheForScope(scope string) *LinkCache {
+	if scope == LinkCacheAudit {
+		return NewLinkCache(linkCacheTTL())
+	}
+	return globalLinkCache
+}
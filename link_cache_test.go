@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLinkWorkerRefetchesStaleEntries(t *testing.T) {
+	// The link works for the first request and is broken afterwards
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ttl := 50 * time.Millisecond
+	o := LinkCheckOptions{Cache: NewLinkCache(ttl)}
+
+	if results := runLinkWorker(server.URL, o); len(results) != 0 {
+		t.Fatalf("first check: got %v, want the link alive", results)
+	}
+
+	// Within the TTL the cached verdict is used
+	if results := runLinkWorker(server.URL, o); len(results) != 0 || requests.Load() != 1 {
+		t.Fatalf("cached check: got %v after %d requests, want the cached verdict", results, requests.Load())
+	}
+
+	time.Sleep(2 * ttl)
+
+	results := runLinkWorker(server.URL, o)
+	if requests.Load() != 2 {
+		t.Fatalf("got %d requests, want the stale entry re-fetched", requests.Load())
+	}
+	if len(results) != 1 || results[0].check.alive {
+		t.Errorf("stale check: got %v, want the link reported broken", results)
+	}
+}
+
+// runLinkWorker checks a single link with linkWorker and returns what it reported
+func runLinkWorker(link string, o LinkCheckOptions) []linkResult {
+	jobs := make(chan string, 1)
+	results := make(chan linkResult, 1)
+	jobs <- link
+	close(jobs)
+	linkWorker(jobs, results, o)
+	close(results)
+
+	reported := []linkResult{}
+	for result := range results {
+		reported = append(reported, result)
+	}
+	return reported
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// LinkGraph maps each crawled page to the same-host pages it links to
+// example: {"https://example.com": ["https://example.com/about", "https://example.com/blog"]}
+type LinkGraph map[string][]string
+
+// AddPage records the outgoing links of a page, skipping duplicates and self-links
+func (g LinkGraph) AddPage(pageURL string, links []string) {
+	seen := make(map[string]bool)
+	targets := make([]string, 0, len(links))
+	for _, link := range links {
+		if link == pageURL || seen[link] {
+			continue
+		}
+		seen[link] = true
+		targets = append(targets, link)
+	}
+	g[pageURL] = targets
+}
+
+// WriteDOT writes the graph in GraphViz DOT format
+func (g LinkGraph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	// Sort sources so the output is stable between runs
+	sources := make([]string, 0, len(g))
+	for source := range g {
+		sources = append(sources, source)
+	}
+	slices.Sort(sources)
+
+	fmt.Fprintln(bw, "digraph site {")
+	for _, source := range sources {
+		fmt.Fprintf(bw, "\t%s;\n", strconv.Quote(source))
+		for _, target := range g[source] {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", strconv.Quote(source), strconv.Quote(target))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Load more rounds, each one scrolls to the bottom and clicks the button if there is one.
+// The most rounds wait 15s in total, leaving half of PageTimeout to load and read the page.
+const (
+	DefaultLoadMoreRounds = 5
+	MaxLoadMoreRounds     = 20
+)
+
+// loadMoreWait is how long new content gets to render after each round
+const loadMoreWait = 750 * time.Millisecond
+
+// LoadMore reveals infinite-scroll and "load more" content before the page is read
+type LoadMore struct {
+	// Selector of the "load more" button, empty to only scroll
+	Selector string `json:"selector"`
+	// MaxClicks bounds the rounds, 0 uses DefaultLoadMoreRounds
+	MaxClicks int `json:"max_clicks"`
+}
+
+func (l *LoadMore) Validate() error {
+	if l.MaxClicks < 0 || l.MaxClicks > MaxLoadMoreRounds {
+		return fmt.Errorf("load_more.max_clicks must be between 0 and %d", MaxLoadMoreRounds)
+	}
+	return nil
+}
+
+// loadMoreJS scrolls to the bottom, clicks the button if it's on the page and
+// returns whether it was clicked. %s is the JSON encoded selector.
+const loadMoreJS = `(() => {
+	window.scrollTo(0, document.body.scrollHeight);
+	const selector = %s;
+	const button = selector && document.querySelector(selector);
+	if (!button || button.disabled || button.offsetParent === null) {
+		return false;
+	}
+	button.scrollIntoView();
+	button.click();
+	return true;
+})()`
+
+// loadMoreContent runs up to MaxClicks rounds after the page loaded, stopping early
+// once the page stops growing and there is no button left to click
+func loadMoreContent(l LoadMore) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		selector, err := json.Marshal(l.Selector)
+		if err != nil {
+			return err
+		}
+
+		var height int
+		if err := chromedp.EvaluateAsDevTools(`document.body.scrollHeight`, &height).Do(ctx); err != nil {
+			return err
+		}
+
+		for range orDefault(l.MaxClicks, DefaultLoadMoreRounds) {
+			var clicked bool
+			if err := chromedp.EvaluateAsDevTools(fmt.Sprintf(loadMoreJS, selector), &clicked).Do(ctx); err != nil {
+				return err
+			}
+			if err := chromedp.Sleep(loadMoreWait).Do(ctx); err != nil {
+				return err
+			}
+
+			var newHeight int
+			if err := chromedp.EvaluateAsDevTools(`document.body.scrollHeight`, &newHeight).Do(ctx); err != nil {
+				return err
+			}
+			if !clicked && newHeight <= height {
+				break
+			}
+			height = newHeight
+		}
+
+		return nil
+	})
+}
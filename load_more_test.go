@@ -0,0 +1,9 @@
+package main
+
+import "testing"
+
+func TestLoadMoreRoundsFitPageTimeout(t *testing.T) {
+	if waited := MaxLoadMoreRounds * loadMoreWait; waited > PageTimeout/2 {
+		t.Errorf("%d rounds wait %s, more than half of the %s page timeout", MaxLoadMoreRounds, waited, PageTimeout)
+	}
+}
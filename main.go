@@ -1,18 +1,106 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// DefaultShutdownTimeout is how long in-flight requests get to finish after SIGTERM or
+// SIGINT, override with SHUTDOWN_TIMEOUT (e.g. "2m")
+const DefaultShutdownTimeout = 30 * time.Second
+
+// shutdownTimeout reads SHUTDOWN_TIMEOUT, falling back to DefaultShutdownTimeout
+func shutdownTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return DefaultShutdownTimeout
+	}
+	return timeout
+}
+
 func main() {
+	setupLogging()
+
+	shutdownTracing, err := setupTracing(context.Background())
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "5000"
 	}
-	log.Printf("Starting scraper server on port %s", port)
-	http.HandleFunc("/scrape", scrapeSiteHandler)
-	http.HandleFunc("/audit", auditListHandler)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+
+	// Scrape requests share one Chrome instead of launching one per request
+	browser, err := NewSharedBrowser(BrowserOptions{})
+	if err != nil {
+		slog.Error("failed to start chrome", "error", err)
+		os.Exit(1)
+	}
+	scrapeBrowser = browser
+
+	slog.Info("starting scraper server", "port", port)
+	http.HandleFunc("/scrape", instrument("/scrape", scrapeSiteHandler))
+	http.HandleFunc("/audit", instrument("/audit", auditListHandler))
+	http.HandleFunc("/audit/validate", instrument("/audit/validate", auditValidateHandler))
+	http.HandleFunc("/audit/async", instrument("/audit/async", auditAsyncHandler))
+	http.HandleFunc("/audit/status", instrument("/audit/status", auditStatusHandler))
+	http.HandleFunc("/audit/cancel", instrument("/audit/cancel", auditCancelHandler))
+	http.HandleFunc("/audit/graph", instrument("/audit/graph", auditGraphHandler))
+	http.HandleFunc("/audit/report", instrument("/audit/report", auditReportHandler))
+	http.HandleFunc("/audit/sitemap", instrument("/audit/sitemap", auditSitemapHandler))
+	http.HandleFunc("/extract", instrument("/extract", extractHandler))
+	http.HandleFunc("/compare", instrument("/compare", compareHandler))
+	http.HandleFunc("/pdf", instrument("/pdf", pdfHandler))
+	http.HandleFunc("/metrics", metricsHandler)
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: withRequestID(rateLimit(http.DefaultServeMux)),
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+		scrapeBrowser.Close()
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
+	case sig := <-stop:
+		slog.Info("shutting down", "signal", sig.String())
+	}
+
+	// Stop accepting connections and let in-flight requests finish, cancel the async
+	// audits and wait for them to wind down, then close Chrome so no browser processes
+	// outlive the server
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("requests still running at the shutdown deadline", "error", err)
+	}
+	if err := jobStore.Shutdown(ctx); err != nil {
+		slog.Warn("async audits still running at the shutdown deadline", "error", err)
+	}
+	scrapeBrowser.Close()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("failed to flush spans", "error", err)
+	}
+
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("server failed", "error", err)
+	}
+	slog.Info("scraper server stopped")
 }
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// checkDurationBuckets are the histogram upper bounds in seconds. Most checks work on
+// already extracted data, link and canonical checks wait on the network.
+var checkDurationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30}
+
+// pageLoadBuckets are the histogram upper bounds in seconds, pages time out after 30s
+var pageLoadBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 3, 5, 8, 13, 20, 30}
+
+// Page outcomes for the outcome label of scraper_pages_total
+const (
+	PageOutcomeSuccess = "success"
+	PageOutcomeTimeout = "timeout"
+	PageOutcomeError   = "error"
+)
+
+// metricsOtherTenant is the project_id/client_id label of tenants that aren't allowlisted
+const metricsOtherTenant = "other"
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scraper_requests_total",
+		Help: "HTTP requests by endpoint and outcome (success for 2xx/3xx, client_error, server_error).",
+	}, []string{"endpoint", "outcome"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scraper_request_duration_seconds",
+		Help:    "Time to answer HTTP requests, streaming audits included.",
+		Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"endpoint"})
+
+	pagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scraper_pages_total",
+		Help: "Pages scraped or audited by endpoint, outcome (success, timeout, error) and allowlisted tenant.",
+	}, []string{"endpoint", "outcome", "project_id", "client_id"})
+
+	pageLoadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scraper_page_load_seconds",
+		Help:    "Time from opening the tab to the end of each load phase (startup, navigate, complete).",
+		Buckets: pageLoadBuckets,
+	}, []string{"endpoint", "phase"})
+
+	checkDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scraper_check_duration_seconds",
+		Help:    "Time spent running each audit check on a page.",
+		Buckets: checkDurationBuckets,
+	}, []string{"check"})
+)
+
+func init() {
+	// The job store is read when scraped rather than updated on every change
+	for _, status := range []JobStatus{JobRunning, JobDone, JobFailed, JobCancelled} {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "scraper_jobs",
+			Help:        "Audit jobs held in the job store by status.",
+			ConstLabels: prometheus.Labels{"status": string(status)},
+		}, func() float64 {
+			return float64(jobStore.Counts()[status])
+		})
+	}
+}
+
+// observeCheckDuration records how long a run of a check took
+func observeCheckDuration(check string, d time.Duration) {
+	checkDuration.WithLabelValues(check).Observe(d.Seconds())
+}
+
+// observePage counts a scraped or audited page, tenant is empty outside audits
+func observePage(endpoint string, tenant Tenant, outcome string) {
+	pagesTotal.WithLabelValues(endpoint, outcome,
+		metricsTenantLabel(tenant.ProjectID, "METRICS_PROJECT_IDS"),
+		metricsTenantLabel(tenant.ClientID, "METRICS_CLIENT_IDS"),
+	).Inc()
+}
+
+// metricsTenantLabel returns id if it's listed in the comma separated env allowlist
+// and metricsOtherTenant otherwise, so clients can't create a series per made up ID
+func metricsTenantLabel(id string, env string) string {
+	if id == "" {
+		return ""
+	}
+	allowed := strings.Split(os.Getenv(env), ",")
+	if slices.ContainsFunc(allowed, func(a string) bool {
+		return strings.TrimSpace(a) == id
+	}) {
+		return id
+	}
+	return metricsOtherTenant
+}
+
+// auditPageOutcome is the outcome label of an audited page
+func auditPageOutcome(result AuditPageResult) string {
+	switch {
+	case result.TimedOut:
+		return PageOutcomeTimeout
+	case result.Error != "":
+		return PageOutcomeError
+	default:
+		return PageOutcomeSuccess
+	}
+}
+
+// observePageLoad records how long after startTime a page reached a load phase
+func observePageLoad(endpoint string, phase string, startTime time.Time) {
+	pageLoadDuration.WithLabelValues(endpoint, phase).Observe(time.Since(startTime).Seconds())
+}
+
+// statusRecorder remembers the status a handler answered with. It keeps Flush so
+// streaming handlers still work through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// instrument counts the requests to endpoint by outcome and times them. Each request
+// is traced as a server span, continuing the trace of its traceparent header if any.
+func instrument(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+endpoint, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next(recorder, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+
+		outcome := "success"
+		if recorder.status >= 500 {
+			outcome = "server_error"
+		} else if recorder.status >= 400 {
+			outcome = "client_error"
+		}
+		requestsTotal.WithLabelValues(endpoint, outcome).Inc()
+		requestDuration.WithLabelValues(endpoint).Observe(time.Since(startTime).Seconds())
+	}
+}
+
+// metricsKeyValid checks the bearer token against METRICS_KEY. Without METRICS_KEY the
+// metrics are public, including the allowlisted project and client IDs.
+func metricsKeyValid(r *http.Request) bool {
+	expected := os.Getenv("METRICS_KEY")
+	if expected == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+var promHandler = promhttp.Handler()
+
+// metricsHandler serves the metrics in the Prometheus text format
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !metricsKeyValid(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+		http.Error(w, "Invalid metrics key", http.StatusUnauthorized)
+		return
+	}
+	promHandler.ServeHTTP(w, r)
+}
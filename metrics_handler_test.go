@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestMetricsTenantLabel(t *testing.T) {
+	t.Setenv("METRICS_PROJECT_IDS", "acme, globex")
+	t.Setenv("METRICS_CLIENT_IDS", "")
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"", ""},
+		{"acme", "acme"},
+		{"globex", "globex"},
+		{"initech", metricsOtherTenant},
+		{"Acme", metricsOtherTenant},
+	}
+
+	for _, tt := range tests {
+		if got := metricsTenantLabel(tt.id, "METRICS_PROJECT_IDS"); got != tt.want {
+			t.Errorf("metricsTenantLabel(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+
+	// Without an allowlist every tenant is "other"
+	if got := metricsTenantLabel("acme", "METRICS_CLIENT_IDS"); got != metricsOtherTenant {
+		t.Errorf("metricsTenantLabel without allowlist = %q, want %q", got, metricsOtherTenant)
+	}
+}
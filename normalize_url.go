@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultTrackingParams are always dropped from crawl URLs, params ending in "*" match by prefix
+var DefaultTrackingParams = []string{"utm_*", "gclid", "fbclid", "msclkid", "dclid", "mc_cid", "mc_eid", "_ga", "_gl"}
+
+// NormalizeOptions control which URL differences are ignored when deduplicating crawl URLs
+type NormalizeOptions struct {
+	// IgnoreQuery drops the whole query string, so /page?a=1 and /page?b=2 are one page
+	IgnoreQuery bool
+	// TrackingParams are dropped in addition to DefaultTrackingParams
+	TrackingParams []string
+}
+
+// normalizeURL returns the key used to decide whether two URLs are the same page. The
+// scheme and host are lowercased, default ports, the fragment, tracking params and a
+// trailing slash are dropped: https://X.com:443/a/?utm_source=x#top is https://x.com/a.
+func normalizeURL(rawURL string, o NormalizeOptions) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	if port := parsed.Port(); (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		// Hostname() would also drop the brackets of IPv6 hosts, [::1]:443 is [::1]
+		parsed.Host = strings.TrimSuffix(parsed.Host, ":"+port)
+	}
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	} else if len(parsed.Path) > 1 {
+		parsed.Path = strings.TrimRight(parsed.Path, "/")
+		if parsed.Path == "" {
+			parsed.Path = "/"
+		}
+	}
+	parsed.RawPath = ""
+
+	if o.IgnoreQuery {
+		parsed.RawQuery = ""
+		parsed.ForceQuery = false
+	} else if parsed.RawQuery != "" {
+		query := parsed.Query()
+		for param := range query {
+			if isTrackingParam(param, o.TrackingParams) {
+				query.Del(param)
+			}
+		}
+		parsed.RawQuery = query.Encode()
+	}
+
+	return parsed.String()
+}
+
+// isTrackingParam reports whether a query param is a default or configured tracking param
+func isTrackingParam(param string, extra []string) bool {
+	param = strings.ToLower(param)
+	for _, tracking := range slices.Concat(DefaultTrackingParams, extra) {
+		tracking = strings.ToLower(tracking)
+		if prefix, ok := strings.CutSuffix(tracking, "*"); ok {
+			if strings.HasPrefix(param, prefix) {
+				return true
+			}
+		} else if param == tracking {
+			return true
+		}
+	}
+	return false
+}
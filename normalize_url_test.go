@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"https://X.com:443/a/?utm_source=x#top", "https://x.com/a"},
+		{"http://example.com:80", "http://example.com/"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"https://[::1]:443/a/", "https://[::1]/a"},
+		{"http://[2001:DB8::1]:80/", "http://[2001:db8::1]/"},
+		{"https://[2001:db8::1]:8443/a", "https://[2001:db8::1]:8443/a"},
+		{"https://[::1]/a", "https://[::1]/a"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeURL(tt.rawURL, NormalizeOptions{}); got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.rawURL, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// validatePageURL checks a URL a client asks Chrome to load. Chrome would just as well
+// open file:// URLs, i.e. files of this server, so only absolute http(s) URLs pass.
+func validatePageURL(rawURL string) error {
+	parsed, err := url.ParseRequestURI(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid url %q: expected an absolute http(s) url", rawURL)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestValidatePageURL(t *testing.T) {
+	tests := []struct {
+		rawURL  string
+		wantErr bool
+	}{
+		{"https://example.com/menu", false},
+		{"http://localhost:8080/", false},
+		{"file:///etc/passwd", true},
+		{"chrome://settings", true},
+		{"javascript:alert(1)", true},
+		{"/relative/path", true},
+		{"https://", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		if err := validatePageURL(tt.rawURL); (err != nil) != tt.wantErr {
+			t.Errorf("validatePageURL(%q) = %v, want error %t", tt.rawURL, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExtractRequestRejectsLocalFiles(t *testing.T) {
+	req := ExtractRequest{URLs: []string{"https://example.com/", "file:///etc/passwd"}}
+	if err := req.Validate(); err == nil {
+		t.Error("Validate accepted a file:// url")
+	}
+}
+
+func TestCompareRequestRejectsLocalFiles(t *testing.T) {
+	req := CompareRequest{URLA: "https://example.com/", URLB: "file:///etc/hosts"}
+	if err := req.Validate(); err == nil {
+		t.Error("Validate accepted a file:// url")
+	}
+}
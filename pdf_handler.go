@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	cdpio "github.com/chromedp/cdproto/io"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultPaperSize is used when PDFRequest.PaperSize is empty
+const DefaultPaperSize = "letter"
+
+// PDFPaperSizes are the paper sizes a PDF can be printed on, width and height in
+// inches in portrait orientation
+var PDFPaperSizes = map[string][2]float64{
+	"letter":  {8.5, 11},
+	"legal":   {8.5, 14},
+	"tabloid": {11, 17},
+	"a3":      {11.69, 16.54},
+	"a4":      {8.27, 11.69},
+	"a5":      {5.83, 8.27},
+}
+
+// pdfChunkSize is how much of the PDF is read from Chrome per write to the client
+const pdfChunkSize = 256 * 1024
+
+// PDFRequest renders a page as a PDF, e.g. to archive it
+type PDFRequest struct {
+	URL string `json:"url"`
+	// PaperSize is one of PDFPaperSizes, empty uses DefaultPaperSize
+	PaperSize       string `json:"paper_size"`
+	Landscape       bool   `json:"landscape"`
+	PrintBackground bool   `json:"print_background"`
+}
+
+func (r *PDFRequest) Validate() error {
+	if r.URL == "" {
+		return errors.New("url is required")
+	}
+	if err := validatePageURL(r.URL); err != nil {
+		return err
+	}
+	if _, ok := PDFPaperSizes[r.paperSize()]; !ok {
+		return fmt.Errorf("paper_size must be one of %s", strings.Join(slices.Sorted(maps.Keys(PDFPaperSizes)), ", "))
+	}
+	return nil
+}
+
+func (r *PDFRequest) paperSize() string {
+	if r.PaperSize == "" {
+		return DefaultPaperSize
+	}
+	return strings.ToLower(r.PaperSize)
+}
+
+// pdfHandler streams the rendered page to the client as application/pdf
+func pdfHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validAPIKey(r) {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	var req PDFRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The status and headers are only sent with the first chunk, until then a failed
+	// render can still be answered with an error
+	started := false
+	write := func(chunk []byte) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/pdf")
+			started = true
+		}
+		_, err := w.Write(chunk)
+		return err
+	}
+
+	browserCtx := scrapeBrowser.Context()
+	err := renderPDF(req, withSpan(browserCtx, r.Context()), write)
+	if err != nil && !started && isCrashError(err) {
+		// Relaunch Chrome if it went down and retry once
+		if scrapeBrowser.Recover(browserCtx) == nil {
+			err = renderPDF(req, withSpan(scrapeBrowser.Context(), r.Context()), write)
+		}
+	}
+	if err != nil {
+		observePage("pdf", Tenant{}, scrapeOutcome(err))
+		if started {
+			// Too late for an error response, the client gets a truncated PDF
+			slog.Warn("pdf stream failed", "url", req.URL, "error", err)
+			return
+		}
+		http.Error(w, fmt.Sprintf("failed to render %s: %v", req.URL, err), http.StatusBadGateway)
+		return
+	}
+	observePage("pdf", Tenant{}, PageOutcomeSuccess)
+}
+
+// renderPDF loads the page in a new tab of parentCtx's browser, prints it and passes
+// the PDF to write in chunks as Chrome streams it
+func renderPDF(req PDFRequest, parentCtx context.Context, write func([]byte) error) error {
+	if err := tabPool.Acquire(parentCtx); err != nil {
+		return err
+	}
+	defer tabPool.Release()
+
+	// Context with timeout for this specific page
+	ctx, cancel := context.WithTimeout(parentCtx, 30*time.Second)
+	defer cancel()
+
+	taskCtx, taskCancel := chromedp.NewContext(ctx)
+	defer taskCancel()
+
+	size := PDFPaperSizes[req.paperSize()]
+	var stream cdpio.StreamHandle
+	err := chromedp.Run(taskCtx,
+		chromedp.Navigate(req.URL),
+		chromedp.WaitVisible("body", chromedp.ByQuery),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			_, stream, err = page.PrintToPDF().
+				WithPaperWidth(size[0]).
+				WithPaperHeight(size[1]).
+				WithLandscape(req.Landscape).
+				WithPrintBackground(req.PrintBackground).
+				WithTransferMode(page.PrintToPDFTransferModeReturnAsStream).
+				Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	return chromedp.Run(taskCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		defer cdpio.Close(stream).Do(ctx)
+		for {
+			// cdpio.Read's Do drops the base64Encoded flag, so the command is executed directly
+			var res cdpio.ReadReturns
+			if err := cdp.Execute(ctx, cdpio.CommandRead, cdpio.Read(stream).WithSize(pdfChunkSize), &res); err != nil {
+				return err
+			}
+
+			chunk := []byte(res.Data)
+			if res.Base64encoded {
+				var err error
+				if chunk, err = base64.StdEncoding.DecodeString(res.Data); err != nil {
+					return err
+				}
+			}
+			if len(chunk) > 0 {
+				if err := write(chunk); err != nil {
+					return err
+				}
+			}
+			if res.EOF {
+				return nil
+			}
+		}
+	}))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultHostDelay spaces navigations to the same host when neither delay_ms nor
+// HOST_DELAY is set
+const DefaultHostDelay = 200 * time.Millisecond
+
+// HostPacer spaces out navigations to the same host so audits don't look like a
+// fixed-interval bot. Requests to different hosts are never delayed by each other.
+type HostPacer struct {
+	delay  time.Duration
+	jitter float64 // fraction of delay added or removed at random, 0.3 = ±30%
+	next   map[string]time.Time
+	mu     sync.Mutex
+}
+
+// NewHostPacer returns nil when delay is 0, a nil pacer never waits
+func NewHostPacer(delay time.Duration, jitter float64) *HostPacer {
+	if delay <= 0 {
+		return nil
+	}
+	return &HostPacer{
+		delay:  delay,
+		jitter: jitter,
+		next:   make(map[string]time.Time),
+	}
+}
+
+// hostDelay returns the request's delay_ms, or HOST_DELAY when it is 0, falling back to
+// DefaultHostDelay. HOST_DELAY=0 turns pacing off for requests that don't ask for it.
+func hostDelay(delayMs int) time.Duration {
+	if delayMs > 0 {
+		return time.Duration(delayMs) * time.Millisecond
+	}
+	delay, err := time.ParseDuration(os.Getenv("HOST_DELAY"))
+	if err != nil || delay < 0 {
+		return DefaultHostDelay
+	}
+	return delay
+}
+
+// Wait blocks until a navigation to the host of rawURL is allowed
+func (p *HostPacer) Wait(ctx context.Context, rawURL string) error {
+	if p == nil {
+		return nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	// Reserve the next slot for this host, then sleep outside the lock
+	p.mu.Lock()
+	slot := time.Now()
+	if next, ok := p.next[parsed.Host]; ok && next.After(slot) {
+		slot = next
+	}
+	p.next[parsed.Host] = slot.Add(p.interval())
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// interval returns the base delay randomized by ±jitter
+func (p *HostPacer) interval() time.Duration {
+	spread := float64(p.delay) * p.jitter
+	return p.delay + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// validatePacing checks the delay_ms and jitter_percent request fields
+func validatePacing(delayMs int, jitterPercent int) error {
+	if delayMs < 0 {
+		return errors.New("delay_ms must not be negative")
+	}
+	if jitterPercent < 0 || jitterPercent > 100 {
+		return errors.New("jitter_percent must be between 0 and 100")
+	}
+	return nil
+}
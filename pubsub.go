@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"cloud.google.com/go/pubsub/v2"
@@ -15,6 +15,8 @@ type PubSubMessage struct {
 	TaskID  string      `json:"task_id"`
 	Event   string      `json:"event,omitempty"`
 	Message interface{} `json:"message,omitempty"`
+	// Tenant is also sent as message attributes so subscribers can filter on it
+	Tenant
 }
 
 // Client wraps the Google Cloud PubSub client
@@ -54,12 +56,15 @@ func (c *Client) Publish(data PubSubMessage) error {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	result := c.publisher.Publish(c.ctx, &pubsub.Message{Data: jsonData})
+	result := c.publisher.Publish(c.ctx, &pubsub.Message{
+		Data:       jsonData,
+		Attributes: data.Tenant.Attributes(),
+	})
 
 	// Block until the result is returned and a server-generated ID is returned
 	_, err = result.Get(c.ctx)
 	if err != nil {
-		log.Printf("failed to publish message: %v", err)
+		slog.Error("failed to publish message", "task_id", data.TaskID, "event", data.Event, "error", err)
 		return err
 	}
 
@@ -85,7 +90,7 @@ func (c *Client) Subscribe(taskID string, callback func(data PubSubMessage)) (fu
 
 			var data PubSubMessage
 			if err := json.Unmarshal(msg.Data, &data); err != nil {
-				log.Printf("failed to unmarshal message: %v", err)
+				slog.Error("failed to unmarshal message", "task_id", taskID, "error", err)
 				msg.Nack()
 				return
 			}
@@ -98,7 +103,7 @@ func (c *Client) Subscribe(taskID string, callback func(data PubSubMessage)) (fu
 		})
 
 		if err != nil && ctx.Err() == nil {
-			log.Printf("subscription error: %v", err)
+			slog.Error("subscription error", "task_id", taskID, "error", err)
 		}
 	}()
 
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for the per-client rate limit, override with RATE_LIMIT_RPM and RATE_LIMIT_BURST
+const (
+	DefaultRateLimitPerMinute = 60
+	DefaultRateLimitBurst     = 10
+)
+
+// tokenBucket holds the tokens of one client as of updatedAt
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// RateLimiter is a token bucket per client. Each client starts with burst tokens, a
+// request takes one and they refill at perMinute per minute.
+type RateLimiter struct {
+	buckets   map[string]*tokenBucket
+	perMinute float64
+	burst     float64
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+func NewRateLimiter(perMinute int, burst int) *RateLimiter {
+	return &RateLimiter{
+		buckets:   make(map[string]*tokenBucket),
+		perMinute: float64(perMinute),
+		burst:     float64(burst),
+		lastPrune: time.Now(),
+	}
+}
+
+// rateLimiter limits requests to every handler
+var rateLimiter = NewRateLimiter(rateLimitPerMinute(), rateLimitBurst())
+
+// rateLimitPerMinute reads RATE_LIMIT_RPM, falling back to DefaultRateLimitPerMinute
+func rateLimitPerMinute() int {
+	num, err := strconv.Atoi(os.Getenv("RATE_LIMIT_RPM"))
+	if err != nil || num <= 0 {
+		return DefaultRateLimitPerMinute
+	}
+	return num
+}
+
+// rateLimitBurst reads RATE_LIMIT_BURST, falling back to DefaultRateLimitBurst
+func rateLimitBurst() int {
+	num, err := strconv.Atoi(os.Getenv("RATE_LIMIT_BURST"))
+	if err != nil || num <= 0 {
+		return DefaultRateLimitBurst
+	}
+	return num
+}
+
+// Allow takes a token from the client's bucket. When it is empty it returns false and
+// how long until the next token.
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updatedAt: now}
+		l.buckets[client] = bucket
+	}
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.updatedAt).Minutes()*l.perMinute)
+	bucket.updatedAt = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.perMinute * float64(time.Minute))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// prune drops buckets that have refilled, they are the same as a new one. Runs at most
+// once a minute. Must hold mu.
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	for client, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updatedAt).Minutes()*l.perMinute >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastPrune = now
+}
+
+// rateLimitClient identifies the caller by API key, or by IP when it didn't send a valid
+// one. Invalid keys count against the IP, otherwise a new random key per request would
+// get a fresh bucket every time.
+func rateLimitClient(r *http.Request) string {
+	if validAPIKey(r) {
+		return "key:" + r.URL.Query().Get("api_key")
+	}
+	return "ip:" + clientIP(r)
+}
+
+// clientIP returns the caller's IP. Behind a load balancer RemoteAddr is the balancer's
+// address, which all clients would share, so with TRUST_PROXY set the last
+// X-Forwarded-For entry is used instead, the one the balancer added. Only set it when
+// the service can't be reached without going through the proxy, clients can send any
+// X-Forwarded-For themselves.
+func clientIP(r *http.Request) string {
+	if trustProxy, _ := strconv.ParseBool(os.Getenv("TRUST_PROXY")); trustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimit answers 429 with Retry-After (whole seconds) to clients over their limit
+func rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := rateLimiter.Allow(rateLimitClient(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// validateReferer checks the referer request field, empty means no Referer header is sent.
+// A referer like https://www.google.com/ shows pages as search visitors see them.
+func validateReferer(referer string) error {
+	if referer == "" {
+		return nil
+	}
+	parsed, err := url.ParseRequestURI(referer)
+	if err != nil {
+		return fmt.Errorf("invalid referer %q: %w", referer, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid referer %q: expected an absolute http(s) url", referer)
+	}
+	return nil
+}
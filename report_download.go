@@ -0,0 +1,41 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// validateReportCompress checks the ?compress= query param before any work is done
+func validateReportCompress(r *http.Request) error {
+	compress := r.URL.Query().Get("compress")
+	if compress != "" && compress != "gzip" {
+		return fmt.Errorf("unsupported compress %q, expected \"gzip\"", compress)
+	}
+	return nil
+}
+
+// writeJSONReport encodes report as the response body. With ?compress=gzip the JSON is
+// gzipped while it is encoded, so huge reports are never held in memory twice, and
+// served as an attachment named filename.json.gz meant to be stored as-is.
+func writeJSONReport(w http.ResponseWriter, r *http.Request, filename string, report any) {
+	compress := r.URL.Query().Get("compress")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var body io.Writer = w
+	if compress == "gzip" {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json.gz"))
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		body = gz
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, an encoding error can only cut the body short
+	json.NewEncoder(body).Encode(report)
+}
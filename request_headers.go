@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/net/http/httpguts"
+)
+
+// MaxRequestHeaders bounds the extra headers of a request
+const MaxRequestHeaders = 50
+
+// validateRequestHeaders checks the user_agent and headers request fields, empty means
+// Chrome's own User-Agent and no extra headers
+func validateRequestHeaders(userAgent string, headers map[string]string) error {
+	if !httpguts.ValidHeaderFieldValue(userAgent) {
+		return errors.New("invalid user_agent")
+	}
+	if len(headers) > MaxRequestHeaders {
+		return fmt.Errorf("at most %d headers are allowed", MaxRequestHeaders)
+	}
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value for header %q", name)
+		}
+	}
+	return nil
+}
+
+// setRequestHeaders sends the referer, the extra headers and the User-Agent with every
+// request of the tab, the document as well as scripts, styles and XHR, including those
+// to third-party hosts like CDNs and analytics. Chrome only keeps the last set of extra
+// headers, so they are all set at once. Must run after network.Enable and before navigating.
+func setRequestHeaders(referer string, userAgent string, headers map[string]string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		extra := network.Headers{}
+		for name, value := range headers {
+			extra[name] = value
+		}
+		if referer != "" {
+			extra["Referer"] = referer
+		}
+		if len(extra) > 0 {
+			if err := network.SetExtraHTTPHeaders(extra).Do(ctx); err != nil {
+				return err
+			}
+		}
+		if userAgent != "" {
+			// Also changes navigator.userAgent, unlike a User-Agent extra header
+			return emulation.SetUserAgentOverride(userAgent).Do(ctx)
+		}
+		return nil
+	})
+}
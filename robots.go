@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// robotsRule is a single Allow/Disallow line
+type robotsRule struct {
+	allow   bool
+	length  int // pattern length, the longest matching rule wins
+	pattern *regexp.Regexp
+}
+
+// RobotsRules holds the robots.txt rules that apply to the default user agent (*) on one host
+type RobotsRules struct {
+	host  string
+	rules []robotsRule
+}
+
+var robotsClient = &http.Client{
+	Timeout:       10 * time.Second,
+	CheckRedirect: capRedirects,
+}
+
+// fetchRobots loads /robots.txt for the host of siteURL. A missing or unreadable
+// robots.txt allows everything, so nil is returned in that case.
+func fetchRobots(siteURL string) *RobotsRules {
+	parsed, err := url.Parse(siteURL)
+	if err != nil {
+		return nil
+	}
+
+	robotsURL := url.URL{Scheme: parsed.Scheme, Host: parsed.Host, Path: "/robots.txt"}
+	resp, err := robotsClient.Get(robotsURL.String())
+	if err != nil {
+		slog.Warn("failed to fetch robots.txt", "url", robotsURL.String(), "error", err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	rules := parseRobots(utf8Reader(io.LimitReader(resp.Body, 512*1024), resp.Header.Get("Content-Type")))
+	rules.host = parsed.Host
+	return rules
+}
+
+// parseRobots parses the groups of a robots.txt that apply to user agent *
+func parseRobots(r io.Reader) *RobotsRules {
+	rules := &RobotsRules{}
+
+	applies := false
+	inAgents := false // consecutive user-agent lines form one group
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				applies = false
+			}
+			inAgents = true
+			if value == "*" {
+				applies = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			// An empty Disallow allows everything
+			if !applies || value == "" {
+				continue
+			}
+			rules.rules = append(rules.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: robotsPattern(value),
+			})
+		default:
+			inAgents = false
+		}
+	}
+
+	return rules
+}
+
+// robotsPattern converts a robots.txt path pattern (* wildcard, $ end anchor) to a regexp.
+// Non-ASCII characters are percent-encoded like in the escaped paths it is matched against.
+func robotsPattern(value string) *regexp.Regexp {
+	anchored := strings.HasSuffix(value, "$")
+	value = escapeNonASCII(strings.TrimSuffix(value, "$"))
+
+	pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(value), `\*`, ".*")
+	if anchored {
+		pattern += "$"
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// escapeNonASCII percent-encodes the UTF-8 bytes of non-ASCII characters, e.g. a
+// Disallow: /会員/ rule becomes /%E4%BC%9A%E5%93%A1/
+func escapeNonASCII(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c >= utf8.RuneSelf {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Allowed reports whether the URL may be crawled. URLs on other hosts are always allowed.
+func (r *RobotsRules) Allowed(rawURL string) bool {
+	if r == nil {
+		return true
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host != r.host {
+		return true
+	}
+
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if parsed.RawQuery != "" {
+		path += "?" + parsed.RawQuery
+	}
+
+	// The longest matching rule wins, Allow wins a tie
+	allowed := true
+	matchedLength := -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > matchedLength || (rule.length == matchedLength && rule.allow) {
+			allowed = rule.allow
+			matchedLength = rule.length
+		}
+	}
+	return allowed
+}
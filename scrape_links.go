@@ -0,0 +1,36 @@
+package main
+
+import "net/url"
+
+// scrapeLinksJS returns the href of every link, which the browser already resolved
+// against the page URL
+const scrapeLinksJS = `Array.from(document.querySelectorAll("a[href]"), el => el.href)`
+
+// scrapedLinks returns the distinct http(s) links of a page without their fragments,
+// in document order. With split they are returned as internal (same host as pageURL)
+// and external links, otherwise all of them are in internal.
+func scrapedLinks(pageURL string, hrefs []string, split bool) (internal []string, external []string) {
+	parsedPage, _ := url.Parse(pageURL)
+
+	internal, external = []string{}, []string{}
+	seen := make(map[string]bool)
+	for _, href := range hrefs {
+		parsed, err := url.Parse(href)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			continue
+		}
+		parsed.Fragment = ""
+		link := parsed.String()
+		if seen[link] {
+			continue
+		}
+		seen[link] = true
+
+		if split && (parsedPage == nil || parsed.Host != parsedPage.Host) {
+			external = append(external, link)
+		} else {
+			internal = append(internal, link)
+		}
+	}
+	return internal, external
+}
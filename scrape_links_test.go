@@ -0,0 +1,34 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScrapedLinks(t *testing.T) {
+	hrefs := []string{
+		"https://example.com/menu",
+		"https://example.com/menu#pizza",
+		"https://partner.example.org/",
+		"mailto:info@example.com",
+		"https://example.com/contact",
+		"https://partner.example.org/#top",
+		"https://example.com/menu",
+	}
+
+	internal, external := scrapedLinks(testPageURL, hrefs, true)
+	if want := []string{"https://example.com/menu", "https://example.com/contact"}; !slices.Equal(internal, want) {
+		t.Errorf("got internal %v, want %v", internal, want)
+	}
+	if want := []string{"https://partner.example.org/"}; !slices.Equal(external, want) {
+		t.Errorf("got external %v, want %v", external, want)
+	}
+
+	internal, external = scrapedLinks(testPageURL, hrefs, false)
+	if want := []string{"https://example.com/menu", "https://partner.example.org/", "https://example.com/contact"}; !slices.Equal(internal, want) {
+		t.Errorf("without split got %v, want %v", internal, want)
+	}
+	if len(external) != 0 {
+		t.Errorf("without split got external %v, want none", external)
+	}
+}
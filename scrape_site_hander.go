@@ -8,10 +8,12 @@ import (
 	"os"
 	"strconv"
 	"sync"
-
-	"github.com/chromedp/chromedp"
 )
 
+// scrapeBrowser is started once in main and shared by all scrape, extract, compare
+// and PDF requests, each page gets its own tab
+var scrapeBrowser *SharedBrowser
+
 type ScrapeResponse struct {
 	Results []ScrapeResult `json:"results"`
 }
@@ -19,13 +21,14 @@ type ScrapeResponse struct {
 // AuditRequest structure
 type ScrapeRequest struct {
 	URLs []string `json:"urls"`
+	ScrapeOptions
 }
 
 func (r *ScrapeRequest) Validate() error {
 	if len(r.URLs) == 0 {
 		return errors.New("no target urls provided")
 	}
-	return nil
+	return r.ScrapeOptions.Validate()
 }
 
 func scrapeSiteHandler(w http.ResponseWriter, r *http.Request) {
@@ -42,9 +45,7 @@ func scrapeSiteHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	query := r.URL.Query()
-	apiKey := query.Get("api_key")
-	if apiKey != os.Getenv("API_KEY") {
+	if !validAPIKey(r) {
 		http.Error(w, "Invalid API key", http.StatusUnauthorized)
 		return
 	}
@@ -59,32 +60,10 @@ func scrapeSiteHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	opts := append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Headless,
-		chromedp.DisableGPU,
-		chromedp.NoSandbox,
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.Flag("mute-audio", true),
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("disable-setuid-sandbox", true),
-		chromedp.Flag("no-zygote", true),
-		chromedp.Flag("disable-background-networking", true),
-		chromedp.Flag("disable-default-apps", true),
-		chromedp.Flag("disable-sync", true),
-		chromedp.Flag("disable-translate", true),
-		chromedp.Flag("blink-settings", "imagesEnabled=false"),
-		chromedp.Flag("disable-remote-fonts", true),
-		chromedp.Flag("disable-background-timer-throttling", true),
-		chromedp.Flag("disable-renderer-backgrounding", true),
-		chromedp.Flag("disable-backgrounding-occluded-windows", true),
-		chromedp.Flag("disable-renderer-backgrounding", true),
-		chromedp.Flag("disable-features", "BackForwardCache"),
-	)
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer allocCancel()
+	if err := validateReportCompress(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 
@@ -102,10 +81,20 @@ func scrapeSiteHandler(w http.ResponseWriter, r *http.Request) {
 				default:
 				}
 
-				result, err := Scrape(url, allocCtx)
-				if err == nil {
-					resultsChannel <- *result
+				browserCtx := scrapeBrowser.Context()
+				result, err := Scrape(url, withSpan(browserCtx, r.Context()), req.ScrapeOptions)
+				if err != nil && isCrashError(err) {
+					// Relaunch Chrome if it went down and retry once
+					if scrapeBrowser.Recover(browserCtx) == nil {
+						result, err = Scrape(url, withSpan(scrapeBrowser.Context(), r.Context()), req.ScrapeOptions)
+					}
+				}
+				if err != nil {
+					observePage("scrape", Tenant{}, scrapeOutcome(err))
+					continue
 				}
+				observePage("scrape", Tenant{}, PageOutcomeSuccess)
+				resultsChannel <- *result
 			}
 		})
 	}
@@ -121,12 +110,13 @@ func scrapeSiteHandler(w http.ResponseWriter, r *http.Request) {
 		output = append(output, result)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	writeJSONReport(w, r, "scrape", ScrapeResponse{Results: output})
+}
 
-	jsonErr := json.NewEncoder(w).Encode(ScrapeResponse{Results: output})
-	if jsonErr != nil {
-		http.Error(w, jsonErr.Error(), http.StatusInternalServerError)
-		return
+// scrapeOutcome is the outcome label of a page that failed to scrape
+func scrapeOutcome(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return PageOutcomeTimeout
 	}
+	return PageOutcomeError
 }
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"log/slog"
 	"strings"
 	"time"
 
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Response structure
@@ -16,29 +20,151 @@ type ScrapeResult struct {
 	Heading    int    `json:"headings"`
 	Paragraphs int    `json:"paragraphs"`
 	Words      int    `json:"words"`
+	StatusCode int    `json:"status_code"`
+	// Selected is the text of every element matching each of ScrapeOptions.Selectors
+	Selected map[string][]string `json:"selected,omitempty"`
+	// Screenshot is the PNG requested with ScrapeOptions.Screenshot, base64 in JSON
+	Screenshot []byte `json:"screenshot,omitempty"`
+	// HTML is the rendered document, only read with ScrapeOptions.IncludeHTML
+	HTML string `json:"html,omitempty"`
+	// Links are the distinct absolute http(s) links of the page, only read with
+	// ScrapeOptions.IncludeLinks. With SplitLinks they are returned as InternalLinks,
+	// on the scraped URL's host, and ExternalLinks instead.
+	Links         []string `json:"links,omitempty"`
+	InternalLinks []string `json:"internal_links,omitempty"`
+	ExternalLinks []string `json:"external_links,omitempty"`
 }
 
-func Scrape(url string, parentCtx context.Context) (*ScrapeResult, error) {
+// ScrapeOptions change how a page is loaded, they apply to every URL of a scrape request
+type ScrapeOptions struct {
+	// Referer is sent with the page load, e.g. a search engine URL
+	Referer string `json:"referer"`
+	// UserAgent replaces Chrome's User-Agent and Headers are added to the page load and
+	// all its subresource requests, e.g. for sites that block headless Chrome or need
+	// an auth header. Subresources on other hosts get the headers too, prefer Cookies
+	// for credentials. Empty keeps Chrome's own User-Agent.
+	UserAgent string            `json:"user_agent"`
+	Headers   map[string]string `json:"headers"`
+	// Cookies are set before the page loads, e.g. a session cookie for pages behind a login
+	Cookies []Cookie `json:"cookies"`
+	// WaitFor is how to tell the page is ready to read, see validateWaitFor. Empty waits
+	// for the body to be visible.
+	WaitFor string `json:"wait_for"`
+	// ScrollToBottom scrolls through the page before it is read, so lazy-loaded content
+	// counts towards Words, Images and Paragraphs
+	ScrollToBottom bool `json:"scroll_to_bottom"`
+	// IncludeHTML returns the rendered HTML as ScrapeResult.HTML, off by default as it
+	// is usually much larger than the rest of the result
+	IncludeHTML bool `json:"include_html"`
+	// IncludeLinks returns the page's links as ScrapeResult.Links, SplitLinks as
+	// ScrapeResult.InternalLinks and ExternalLinks
+	IncludeLinks bool `json:"include_links"`
+	SplitLinks   bool `json:"split_links"`
+	// Selectors maps result keys to CSS selectors, see ScrapeResult.Selected
+	Selectors map[string]string `json:"selectors"`
+	// Screenshot captures a PNG of the page after it loaded
+	Screenshot *Screenshot `json:"screenshot"`
+}
+
+func (o *ScrapeOptions) Validate() error {
+	if err := validateReferer(o.Referer); err != nil {
+		return err
+	}
+	if err := validateRequestHeaders(o.UserAgent, o.Headers); err != nil {
+		return err
+	}
+	if err := validateCookies(o.Cookies); err != nil {
+		return err
+	}
+	if err := validateWaitFor(o.WaitFor); err != nil {
+		return err
+	}
+	if err := validateSelectors(o.Selectors); err != nil {
+		return err
+	}
+	if o.Screenshot != nil {
+		if err := o.Screenshot.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scrape loads a page in a new tab of parentCtx's browser and returns its text and
+// element counts. It is traced as a span in the trace of parentCtx.
+func Scrape(url string, parentCtx context.Context, o ScrapeOptions) (*ScrapeResult, error) {
+	ctx, span := tracer.Start(parentCtx, "Scrape", trace.WithAttributes(attribute.String("url.full", url)))
+	result, err := scrape(url, ctx, o)
+	if result != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+	}
+	endSpan(span, err)
+	return result, err
+}
+
+func scrape(url string, parentCtx context.Context, o ScrapeOptions) (*ScrapeResult, error) {
+	if err := tabPool.Acquire(parentCtx); err != nil {
+		return nil, err
+	}
+	defer tabPool.Release()
+
 	// Context with timeout for this specific page
 	ctx, cancel := context.WithTimeout(parentCtx, 30*time.Second)
 	defer cancel()
 
-	// Create a new browser context from the shared allocator
-	taskCtx, taskCancel := chromedp.NewContext(ctx)
+	// Create a new browser context from the shared allocator. The browser is shared by
+	// all scrape requests, so a request's cookies get a browser context of their own
+	// that is thrown away with the tab.
+	var tabOpts []chromedp.ContextOption
+	if len(o.Cookies) > 0 {
+		tabOpts = append(tabOpts, chromedp.WithNewBrowserContext())
+	}
+	taskCtx, taskCancel := chromedp.NewContext(ctx, tabOpts...)
 	defer taskCancel()
 
+	statusCode := listenDocumentStatus(taskCtx)
+	spans := &pageSpans{ctx: parentCtx}
+
 	var pageText string
 	var imgCount int
 	var paragraphCount int
 	var headingsCount int
 
-	err := chromedp.Run(taskCtx,
+	// Load phases are timed from here, startup ends once the tab is open
+	startTime := time.Now()
+	actions := []chromedp.Action{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			observePageLoad("scrape", "startup", startTime)
+			return nil
+		}),
+		// Needed for the document status
+		network.Enable(),
+	}
+	actions = append(actions, setRequestHeaders(o.Referer, o.UserAgent, o.Headers))
+	if len(o.Cookies) > 0 {
+		actions = append(actions, setCookies(o.Cookies, slog.Default()))
+	}
+	if o.Screenshot != nil {
+		actions = append(actions, o.Screenshot.setViewport())
+	}
+	waitSetup, wait := pageWait(taskCtx, o.WaitFor)
+	actions = append(actions, waitSetup)
+
+	actions = append(actions,
+		spans.phase("navigate"),
 		chromedp.Navigate(url),
-		// chromedp.ActionFunc(func(ctx context.Context) error {
-		// 	startup = time.Since(startTime)
-		// 	return nil
-		// }),
-		chromedp.WaitVisible("body", chromedp.ByQuery),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			observePageLoad("scrape", "navigate", startTime)
+			return nil
+		}),
+		wait,
+	)
+	if o.ScrollToBottom {
+		actions = append(actions, scrollToBottom())
+	}
+
+	actions = append(actions,
+		spans.phase("extract"),
 		chromedp.Text("body", &pageText, chromedp.NodeVisible, chromedp.ByQuery),
 		chromedp.EvaluateAsDevTools(`
 			document.querySelectorAll("h1,h2,h3,h4,h5,h6").length
@@ -50,18 +176,49 @@ func Scrape(url string, parentCtx context.Context) (*ScrapeResult, error) {
 			document.querySelectorAll("p").length
 		`, &paragraphCount),
 	)
+
+	var selected map[string][]string
+	if len(o.Selectors) > 0 {
+		actions = append(actions, extractSelectors(o.Selectors, &selected))
+	}
+	var html string
+	if o.IncludeHTML {
+		actions = append(actions, chromedp.EvaluateAsDevTools(`document.documentElement.outerHTML`, &html))
+	}
+	var linkHrefs []string
+	if o.IncludeLinks || o.SplitLinks {
+		actions = append(actions, chromedp.EvaluateAsDevTools(scrapeLinksJS, &linkHrefs))
+	}
+	var screenshot []byte
+	if o.Screenshot != nil {
+		actions = append(actions, spans.phase("screenshot"), o.Screenshot.capture(&screenshot))
+	}
+
+	err := chromedp.Run(taskCtx, actions...)
+	spans.end(err)
 	if err != nil {
 		return nil, err
 	}
+	observePageLoad("scrape", "complete", startTime)
 
 	wordCount := len(strings.Fields(pageText))
 
-	return &ScrapeResult{
+	result := &ScrapeResult{
 		Url:        url,
 		Text:       pageText,
 		Images:     imgCount,
 		Heading:    headingsCount,
 		Paragraphs: paragraphCount,
 		Words:      wordCount,
-	}, nil
+		StatusCode: statusCode(),
+		Selected:   selected,
+		Screenshot: screenshot,
+		HTML:       html,
+	}
+	if o.SplitLinks {
+		result.InternalLinks, result.ExternalLinks = scrapedLinks(url, linkHrefs, true)
+	} else if o.IncludeLinks {
+		result.Links, _ = scrapedLinks(url, linkHrefs, false)
+	}
+	return result, nil
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Screenshot viewport bounds in CSS pixels
+const (
+	DefaultViewportWidth  = 1366
+	DefaultViewportHeight = 768
+	MaxViewportSize       = 4096
+)
+
+// Screenshot asks Scrape for a PNG of the page, returned base64-encoded in ScrapeResult
+type Screenshot struct {
+	// Width and Height of the viewport, 0 uses DefaultViewportWidth/DefaultViewportHeight
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// FullPage captures the whole scrollable page instead of just the viewport
+	FullPage bool `json:"full_page"`
+}
+
+func (s *Screenshot) Validate() error {
+	if s.Width < 0 || s.Width > MaxViewportSize || s.Height < 0 || s.Height > MaxViewportSize {
+		return fmt.Errorf("screenshot width and height must be between 0 and %d", MaxViewportSize)
+	}
+	return nil
+}
+
+// setViewport sizes the tab's viewport, must run before navigating so the page lays
+// out at that size
+func (s Screenshot) setViewport() chromedp.Action {
+	return chromedp.EmulateViewport(int64(orDefault(s.Width, DefaultViewportWidth)), int64(orDefault(s.Height, DefaultViewportHeight)))
+}
+
+// capture takes the PNG into png
+func (s Screenshot) capture(png *[]byte) chromedp.Action {
+	if s.FullPage {
+		// Quality 100 makes FullScreenshot capture a PNG rather than a JPEG
+		return chromedp.FullScreenshot(png, 100)
+	}
+	return chromedp.CaptureScreenshot(png)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Lazy-load scrolling moves down a viewport per step, bounded for endless pages
+const (
+	MaxScrollSteps = 50
+	scrollStepWait = 250 * time.Millisecond
+)
+
+// scrollStepJS scrolls down one viewport and returns whether the bottom was reached.
+// It reads documentElement, body is null on e.g. XML and SVG documents.
+const scrollStepJS = `(() => {
+	window.scrollBy(0, window.innerHeight);
+	return window.scrollY + window.innerHeight >= document.documentElement.scrollHeight;
+})()`
+
+// scrollToBottom scrolls through the page a viewport at a time so lazy-loaded text and
+// images render, then goes back to the top
+func scrollToBottom() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		for range MaxScrollSteps {
+			var bottom bool
+			if err := chromedp.EvaluateAsDevTools(scrollStepJS, &bottom).Do(ctx); err != nil {
+				return err
+			}
+			if err := chromedp.Sleep(scrollStepWait).Do(ctx); err != nil {
+				return err
+			}
+			if bottom {
+				break
+			}
+		}
+		return chromedp.EvaluateAsDevTools(`window.scrollTo(0, 0)`, nil).Do(ctx)
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// MaxSelectors bounds the selectors of a scrape request
+const MaxSelectors = 50
+
+// validateSelectors checks the selectors option of a scrape request, nil is fine
+func validateSelectors(selectors map[string]string) error {
+	if len(selectors) > MaxSelectors {
+		return fmt.Errorf("at most %d selectors are allowed", MaxSelectors)
+	}
+	for key, selector := range selectors {
+		if key == "" {
+			return errors.New("selectors keys must not be empty")
+		}
+		if strings.TrimSpace(selector) == "" {
+			return fmt.Errorf("selector %q must not be empty", key)
+		}
+	}
+	return nil
+}
+
+// selectorsJS returns the trimmed text of every element matching each selector, keyed
+// like the request. Invalid selectors match nothing. %s is the JSON encoded selectors.
+const selectorsJS = `(() => {
+	const selectors = %s;
+	const selected = {};
+	for (const [key, selector] of Object.entries(selectors)) {
+		try {
+			selected[key] = Array.from(document.querySelectorAll(selector), el => (el.innerText || el.textContent || "").trim());
+		} catch (e) {
+			selected[key] = [];
+		}
+	}
+	return selected;
+})()`
+
+// extractSelectors reads the text of the elements matching selectors into selected
+func extractSelectors(selectors map[string]string, selected *map[string][]string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		encoded, err := json.Marshal(selectors)
+		if err != nil {
+			return err
+		}
+		return chromedp.EvaluateAsDevTools(fmt.Sprintf(selectorsJS, encoded), selected).Do(ctx)
+	})
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// MaxSitemapFiles caps how many sitemap files (index + children) are fetched per audit
+const MaxSitemapFiles = 50
+
+// sitemapDocument matches both <urlset> and <sitemapindex> documents
+type sitemapDocument struct {
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+var sitemapClient = &http.Client{
+	Timeout:       15 * time.Second,
+	CheckRedirect: capRedirects,
+}
+
+// fetchSitemapURLs returns the page URLs listed in the sitemap of baseHost (scheme and
+// host, e.g. https://example.com). It tries /sitemap.xml then /sitemap.xml.gz and follows
+// sitemap index files. A missing sitemap is not an error, it just returns no URLs.
+func fetchSitemapURLs(baseHost string) ([]string, error) {
+	baseHost = strings.TrimSuffix(baseHost, "/")
+
+	pageURLs := []string{}
+	seenPages := make(map[string]bool)
+	seenSitemaps := make(map[string]bool)
+
+	queue := []string{baseHost + "/sitemap.xml"}
+	for len(queue) > 0 && len(seenSitemaps) < MaxSitemapFiles {
+		sitemapURL := queue[0]
+		queue = queue[1:]
+		if seenSitemaps[sitemapURL] {
+			continue
+		}
+		seenSitemaps[sitemapURL] = true
+
+		doc, found, err := fetchSitemap(sitemapURL)
+		if err != nil {
+			return pageURLs, err
+		}
+		if !found {
+			// Fall back to the compressed root sitemap
+			if sitemapURL == baseHost+"/sitemap.xml" {
+				queue = append(queue, baseHost+"/sitemap.xml.gz")
+			}
+			continue
+		}
+
+		for _, sitemap := range doc.Sitemaps {
+			queue = append(queue, strings.TrimSpace(sitemap.Loc))
+		}
+		for _, page := range doc.URLs {
+			loc := strings.TrimSpace(page.Loc)
+			if loc != "" && !seenPages[loc] {
+				seenPages[loc] = true
+				pageURLs = append(pageURLs, loc)
+			}
+		}
+	}
+
+	return pageURLs, nil
+}
+
+// fetchSitemap downloads and parses a single sitemap file, found is false on non-200 responses
+func fetchSitemap(sitemapURL string) (doc sitemapDocument, found bool, err error) {
+	resp, err := sitemapClient.Get(sitemapURL)
+	if err != nil {
+		return doc, false, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return doc, false, nil
+	}
+
+	// Sniff the gzip magic bytes rather than trusting the extension or Content-Type
+	var body io.Reader = bufio.NewReader(io.LimitReader(resp.Body, 50*1024*1024))
+	magic, _ := body.(*bufio.Reader).Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return doc, false, fmt.Errorf("failed to decompress %s: %w", sitemapURL, err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	// Sitemaps declaring another encoding (e.g. <?xml encoding="ISO-8859-1"?>) are transcoded
+	decoder := xml.NewDecoder(body)
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(&doc); err != nil {
+		return doc, false, fmt.Errorf("failed to parse %s: %w", sitemapURL, err)
+	}
+
+	return doc, true, nil
+}
+
+// sitemapDiff compares the sitemap with what the crawl found. Sitemap pages no crawled
+// page links to get WarningSitemapUnreachable, crawled pages missing from the sitemap get
+// WarningNotInSitemap. URLs are compared by their dedup key.
+func sitemapDiff(sitemapURLs []string, crawledURLs []string, graph LinkGraph, startURL string, key func(string) string) WarningMap {
+	warnings := make(WarningMap)
+
+	inSitemap := make(map[string]bool)
+	for _, sitemapURL := range sitemapURLs {
+		inSitemap[key(sitemapURL)] = true
+	}
+
+	linked := map[string]bool{key(startURL): true}
+	for _, targets := range graph {
+		for _, target := range targets {
+			linked[key(target)] = true
+		}
+	}
+
+	for _, sitemapURL := range sitemapURLs {
+		if !linked[key(sitemapURL)] {
+			warnings[WarningSitemapUnreachable] = append(warnings[WarningSitemapUnreachable], []string{sitemapURL})
+		}
+	}
+	for _, crawledURL := range crawledURLs {
+		if !inSitemap[key(crawledURL)] {
+			warnings[WarningNotInSitemap] = append(warnings[WarningNotInSitemap], []string{crawledURL})
+		}
+	}
+
+	return warnings
+}
+
+// sitemapXMLNS is the namespace of generated sitemaps
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapURLSet is a generated <urlset> document
+type sitemapURLSet struct {
+	XMLName xml.Name          `xml:"urlset"`
+	XMLNS   string            `xml:"xmlns,attr"`
+	URLs    []sitemapURLEntry `xml:"url"`
+}
+
+type sitemapURLEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapExcludedWarnings mark crawled pages that don't belong in a sitemap
+var sitemapExcludedWarnings = []WarningType{WarningPageNotOK, WarningRobotsNoindex}
+
+// generateSitemap writes the crawled pages of result as a sitemap.xml, all with
+// crawledAt as lastmod. Pages are deduplicated by normalizeURL, keeping the first URL
+// crawled. Error and noindex pages are left out.
+func generateSitemap(w io.Writer, result *AuditResult, crawledAt time.Time) error {
+	excluded := make(map[string]bool)
+	for _, warningType := range sitemapExcludedWarnings {
+		for _, payload := range result.Warnings[warningType] {
+			excluded[normalizeURL(payload[0], NormalizeOptions{})] = true
+		}
+	}
+
+	urlSet := sitemapURLSet{XMLNS: sitemapXMLNS, URLs: []sitemapURLEntry{}}
+	lastMod := crawledAt.UTC().Format(time.RFC3339)
+	seen := make(map[string]bool)
+	for _, pageURL := range result.Pages {
+		key := normalizeURL(pageURL, NormalizeOptions{})
+		if seen[key] || excluded[key] {
+			continue
+		}
+		seen[key] = true
+
+		urlSet.URLs = append(urlSet.URLs, sitemapURLEntry{Loc: sitemapLoc(pageURL), LastMod: lastMod})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(urlSet)
+}
+
+// sitemapLoc lowercases the scheme and host of a crawled URL and drops its fragment.
+// Unlike normalizeURL it keeps the path and query as the site links to them.
+func sitemapLoc(pageURL string) string {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
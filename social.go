@@ -0,0 +1,64 @@
+package main
+
+import "strings"
+
+// OpenGraphCoreProperties are needed for a proper link preview when the page is shared
+var OpenGraphCoreProperties = []string{"og:title", "og:description", "og:image", "og:url"}
+
+// checkOpenGraph warns when core Open Graph properties are missing or empty.
+// Payload: page URL, then the missing property names.
+func checkOpenGraph(ogTags map[string]string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	missing := []string{}
+	for _, property := range OpenGraphCoreProperties {
+		if strings.TrimSpace(ogTags[property]) == "" {
+			missing = append(missing, property)
+		}
+	}
+
+	if len(missing) > 0 {
+		warnings[WarningOpenGraphIncomplete] = append([]string{pageURL}, missing...)
+	}
+
+	return warnings
+}
+
+// TwitterCardRequired are the fields each card type needs. title, description and image
+// fall back to their Open Graph counterparts, like X itself does.
+var TwitterCardRequired = map[string][]string{
+	"summary":             {"twitter:title"},
+	"summary_large_image": {"twitter:title", "twitter:image"},
+	"app":                 {"twitter:app:id:iphone", "twitter:app:id:googleplay"},
+	"player":              {"twitter:title", "twitter:site", "twitter:player", "twitter:player:width", "twitter:player:height", "twitter:image"},
+}
+
+// checkTwitterCard warns when there is no twitter:card, or when the declared card type
+// lacks required fields. Payloads: page URL (missing), page URL, card type, then the
+// missing fields (incomplete). Unknown card types aren't checked.
+func checkTwitterCard(twitterTags map[string]string, ogTags map[string]string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	cardType := strings.TrimSpace(twitterTags["twitter:card"])
+	if cardType == "" {
+		warnings[WarningTwitterCardMissing] = []string{pageURL}
+		return warnings
+	}
+
+	missing := []string{}
+	for _, field := range TwitterCardRequired[cardType] {
+		if strings.TrimSpace(twitterTags[field]) != "" {
+			continue
+		}
+		if og, ok := strings.CutPrefix(field, "twitter:"); ok && strings.TrimSpace(ogTags["og:"+og]) != "" {
+			continue
+		}
+		missing = append(missing, field)
+	}
+
+	if len(missing) > 0 {
+		warnings[WarningTwitterCardIncomplete] = append([]string{pageURL, cardType}, missing...)
+	}
+
+	return warnings
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultSoftNotFoundPhrases are matched on every page, by language. Clients add
+// their own with Checks.SoftNotFoundPhrases.
+var DefaultSoftNotFoundPhrases = map[string][]string{
+	"en": {"page not found", "404", "page doesn't exist", "page does not exist", "no longer available"},
+	"de": {"seite nicht gefunden", "seite existiert nicht", "nicht mehr verfügbar"},
+	"fr": {"page introuvable", "page non trouvée", "page n'existe pas"},
+	"es": {"página no encontrada", "la página no existe", "no se ha encontrado"},
+	"it": {"pagina non trovata", "la pagina non esiste"},
+	"pt": {"página não encontrada", "a página não existe"},
+	"nl": {"pagina niet gevonden", "pagina bestaat niet"},
+	"pl": {"nie znaleziono strony", "strona nie istnieje"},
+	"ja": {"ページが見つかりません", "お探しのページは見つかりませんでした"},
+	"zh": {"页面未找到", "找不到页面", "页面不存在"},
+}
+
+// Soft 404 confidence. A phrase in the title or H1 is a strong signal, in the body only
+// a weak one that makes it more likely when the page has little else on it. A body match
+// alone stays below MinSoftNotFoundConfidence, however short the page, since "404" or
+// "no longer available" turn up in plenty of short pages that aren't errors. Error pages
+// that are near-duplicates of each other are raised by SoftNotFoundDuplicateBoost after
+// the crawl.
+const (
+	SoftNotFoundTitleScore     = 0.5
+	SoftNotFoundH1Score        = 0.3
+	SoftNotFoundTextScore      = 0.2
+	SoftNotFoundLowContent     = 0.2
+	SoftNotFoundDuplicateBoost = 0.3
+	SoftNotFoundMaxWords       = 150 // pages with fewer words count as low content
+	MinSoftNotFoundConfidence  = 0.5
+)
+
+// matchesPhrase matches whole words for phrases with word boundaries (latin scripts),
+// and a plain substring for scripts without spaces (CJK)
+func matchesPhrase(text string, phrase string) bool {
+	if strings.IndexFunc(phrase, func(r rune) bool { return r > unicode.MaxASCII }) == -1 {
+		return containsPhrase(text, phrase)
+	}
+	return strings.Contains(strings.ToLower(strings.Join(strings.Fields(text), " ")), strings.ToLower(phrase))
+}
+
+// softNotFoundScore returns the confidence that a 200 page is really an error page and
+// the phrase that matched, 0 if no phrase did
+func softNotFoundScore(title string, h1Texts []string, pageText string, phrases []string) (float64, string) {
+	h1 := strings.Join(h1Texts, " | ")
+
+	var score float64
+	var matched string
+	for _, phrase := range phrases {
+		var phraseScore float64
+		if matchesPhrase(title, phrase) {
+			phraseScore += SoftNotFoundTitleScore
+		}
+		if matchesPhrase(h1, phrase) {
+			phraseScore += SoftNotFoundH1Score
+		}
+		if matchesPhrase(pageText, phrase) {
+			phraseScore += SoftNotFoundTextScore
+		}
+		if phraseScore > score {
+			score, matched = phraseScore, phrase
+		}
+	}
+	if matched == "" {
+		return 0, ""
+	}
+
+	if countWords(pageText) < SoftNotFoundMaxWords {
+		score += SoftNotFoundLowContent
+	}
+	return min(score, 1), matched
+}
+
+// checkSoftNotFound warns about pages that look like a "not found" page.
+// Payload: page URL, confidence with two decimals, matched phrase.
+func checkSoftNotFound(title string, h1Texts []string, pageText string, phrases []string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if score, phrase := softNotFoundScore(title, h1Texts, pageText, phrases); score >= MinSoftNotFoundConfidence {
+		warnings[WarningSoftNotFound] = []string{pageURL, fmt.Sprintf("%.2f", score), phrase}
+	}
+
+	return warnings
+}
+
+// softNotFoundPhrases returns the default phrases of all languages plus the client's own
+func (c Checks) softNotFoundPhrases() []string {
+	phrases := []string{}
+	for _, language := range slices.Sorted(maps.Keys(DefaultSoftNotFoundPhrases)) {
+		phrases = append(phrases, DefaultSoftNotFoundPhrases[language]...)
+	}
+	return append(phrases, c.SoftNotFoundPhrases...)
+}
+
+// contentFingerprint hashes the page text with digits dropped, so error pages that only
+// differ by the requested URL or an error ID hash the same
+func contentFingerprint(pageText string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, strings.Join(strings.Fields(pageText), " "))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// boostDuplicateSoftNotFound raises the confidence of soft 404 pages whose content is
+// the same as another soft 404 page, one template served for many missing pages.
+// fingerprints maps page URLs to their contentFingerprint.
+func boostDuplicateSoftNotFound(pages []PageAuditInfo, fingerprints map[string]string) {
+	counts := make(map[string]int)
+	for _, page := range pages {
+		if len(page.Warnings[WarningSoftNotFound]) > 0 && fingerprints[page.URL] != "" {
+			counts[fingerprints[page.URL]]++
+		}
+	}
+
+	for _, page := range pages {
+		if counts[fingerprints[page.URL]] < 2 {
+			continue
+		}
+		for _, payload := range page.Warnings[WarningSoftNotFound] {
+			if score, err := strconv.ParseFloat(payload[1], 64); err == nil {
+				payload[1] = fmt.Sprintf("%.2f", min(score+SoftNotFoundDuplicateBoost, 1))
+			}
+		}
+	}
+}
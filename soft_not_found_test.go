@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSoftNotFound(t *testing.T) {
+	phrases := Checks{}.softNotFoundPhrases()
+	longText := strings.Repeat("Fresh pasta made daily with flour from local mills. ", 30)
+
+	tests := []struct {
+		name     string
+		title    string
+		h1Texts  []string
+		pageText string
+		want     WarningType
+	}{
+		{"phrase in title", "Page not found | Da Mario", []string{"Sorry"}, "Sorry, try the menu.", WarningSoftNotFound},
+		{"phrase in H1 of a short page", "Da Mario", []string{"Page not found"}, "Page not found. Try the menu.", WarningSoftNotFound},
+		{"phrase in H1 of a long page", "Da Mario", []string{"Page not found"}, "Page not found. " + longText, WarningSoftNotFound},
+		{"phrase only in the body of a short page", "Opening hours", []string{"Opening hours"}, "Our old menu is no longer available, call us for today's dishes.", ""},
+		{"phrase only in the body of a long page", "Menu", []string{"Menu"}, longText + "Our winter menu is no longer available.", ""},
+		{"no phrase", "Menu", []string{"Menu"}, longText, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := checkSoftNotFound(tt.title, tt.h1Texts, tt.pageText, phrases, testPageURL)
+			assertOnlyWarning(t, warnings, tt.want)
+		})
+	}
+}
+
+func TestSoftNotFoundScoreCJKContent(t *testing.T) {
+	phrases := Checks{}.softNotFoundPhrases()
+	// One H1 match, a long Japanese article isn't low content
+	article := strings.Repeat("ナポリ風のピザを毎日薪窯で焼いています。", 15)
+	if score, _ := softNotFoundScore("メニュー", []string{"ページが見つかりません"}, article, phrases); score != SoftNotFoundH1Score {
+		t.Errorf("long Japanese page: got score %.2f, want %.2f", score, SoftNotFoundH1Score)
+	}
+
+	short := "ページが見つかりません。トップへ戻る"
+	if score, _ := softNotFoundScore("メニュー", []string{"ページが見つかりません"}, short, phrases); score != SoftNotFoundH1Score+SoftNotFoundTextScore+SoftNotFoundLowContent {
+		t.Errorf("short Japanese page: got score %.2f, want it counted as low content", score)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// spaRouteRecorder is injected before any page script runs and records every
+// client-side route change made through the history API
+const spaRouteRecorder = `(() => {
+	window.__spaRoutes = [];
+	const record = (url) => {
+		if (url) {
+			try { window.__spaRoutes.push(new URL(url, location.href).href); } catch (e) {}
+		}
+	};
+	for (const method of ["pushState", "replaceState"]) {
+		const original = history[method];
+		history[method] = function (state, title, url) {
+			record(url);
+			return original.apply(this, arguments);
+		};
+	}
+})()`
+
+// spaRoutesJS collects the recorded history routes plus router links that
+// aren't plain a[href] elements (Angular routerLink, data-href/data-route)
+const spaRoutesJS = `(() => {
+	const routes = new Set(window.__spaRoutes || []);
+	document.querySelectorAll("[routerlink], [data-href], [data-route]").forEach(el => {
+		const route = el.getAttribute("routerlink") || el.dataset.href || el.dataset.route;
+		try { routes.add(new URL(route, location.href).href); } catch (e) {}
+	});
+	return Array.from(routes);
+})()`
+
+// spaBeforeNavigate installs the history recorder, must run before chromedp.Navigate
+func spaBeforeNavigate() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		_, err := page.AddScriptToEvaluateOnNewDocument(spaRouteRecorder).Do(ctx)
+		return err
+	})
+}
+
+// spaAfterLoad waits for the client-side app to render and collects its routes
+func spaAfterLoad(routes *[]string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		// Deep links are often an empty shell until the router renders the
+		// view, an empty body after the timeout still gets audited as is
+		_ = chromedp.Poll(`document.body.innerText.trim().length > 0`, nil,
+			chromedp.WithPollingTimeout(5*time.Second)).Do(ctx)
+
+		return chromedp.EvaluateAsDevTools(spaRoutesJS, routes).Do(ctx)
+	})
+}
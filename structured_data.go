@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// checkStructuredData warns when the page has no JSON-LD or when a block doesn't parse.
+// Invalid payload: page URL, the @type values of the blocks that parsed (comma separated,
+// empty if none did), then the parse error of each invalid block.
+func checkStructuredData(jsonLDBlocks []string, pageURL string) map[WarningType][]string {
+	warnings := make(map[WarningType][]string)
+
+	if len(jsonLDBlocks) == 0 {
+		warnings[WarningStructuredDataMissing] = []string{pageURL}
+		return warnings
+	}
+
+	types := []string{}
+	parseErrors := []string{}
+	for i, block := range jsonLDBlocks {
+		var data any
+		if err := json.Unmarshal([]byte(block), &data); err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("block %d: %v", i+1, err))
+			continue
+		}
+		types = appendJSONLDTypes(types, data)
+	}
+
+	if len(parseErrors) > 0 {
+		warnings[WarningStructuredDataInvalid] = append([]string{pageURL, strings.Join(types, ", ")}, parseErrors...)
+	}
+
+	return warnings
+}
+
+// appendJSONLDTypes collects the @type values of a parsed block, including nodes in
+// top-level arrays and @graph
+func appendJSONLDTypes(types []string, data any) []string {
+	switch node := data.(type) {
+	case []any:
+		for _, item := range node {
+			types = appendJSONLDTypes(types, item)
+		}
+	case map[string]any:
+		switch t := node["@type"].(type) {
+		case string:
+			types = append(types, t)
+		case []any:
+			for _, item := range t {
+				if s, ok := item.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+		if graph, ok := node["@graph"]; ok {
+			types = appendJSONLDTypes(types, graph)
+		}
+	}
+	return types
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+)
+
+// DefaultMaxBrowserTabs caps open tabs when MAX_BROWSER_TABS isn't set
+const DefaultMaxBrowserTabs = 10
+
+// TabPool limits how many Chrome tabs are open at once across all requests
+type TabPool struct {
+	slots chan struct{}
+}
+
+func NewTabPool(size int) *TabPool {
+	return &TabPool{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// tabPool is shared by audits, scrapes and extracts
+var tabPool = NewTabPool(maxBrowserTabs())
+
+// maxBrowserTabs reads MAX_BROWSER_TABS, falling back to DefaultMaxBrowserTabs
+func maxBrowserTabs() int {
+	num, err := strconv.Atoi(os.Getenv("MAX_BROWSER_TABS"))
+	if err != nil || num <= 0 {
+		return DefaultMaxBrowserTabs
+	}
+	return num
+}
+
+// Acquire blocks until a tab slot is free or ctx is done. Every successful
+// Acquire must be followed by a Release once the tab is closed.
+func (p *TabPool) Acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (p *TabPool) Release() {
+	<-p.slots
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+)
+
+// tenantIDPattern keeps IDs short and label-safe so they can be used in logs,
+// pubsub attributes and metric labels without blowing up cardinality
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Tenant tags an audit with the project/client it was run for. Both fields are optional.
+type Tenant struct {
+	ProjectID string `json:"project_id,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	// CorrelationID is the request or job ID added to every log line, not part of the API
+	CorrelationID string `json:"-"`
+}
+
+// Validate checks that set IDs match tenantIDPattern
+func (t Tenant) Validate() error {
+	if t.ProjectID != "" && !tenantIDPattern.MatchString(t.ProjectID) {
+		return fmt.Errorf("project_id must be at most 64 letters, digits, '_', '.' or '-'")
+	}
+	if t.ClientID != "" && !tenantIDPattern.MatchString(t.ClientID) {
+		return fmt.Errorf("client_id must be at most 64 letters, digits, '_', '.' or '-'")
+	}
+	return nil
+}
+
+// Attributes returns the tenant as pubsub message attributes, nil when untagged
+func (t Tenant) Attributes() map[string]string {
+	if t.ProjectID == "" && t.ClientID == "" {
+		return nil
+	}
+
+	attributes := make(map[string]string)
+	if t.ProjectID != "" {
+		attributes["project_id"] = t.ProjectID
+	}
+	if t.ClientID != "" {
+		attributes["client_id"] = t.ClientID
+	}
+	return attributes
+}
+
+// Logger returns the default logger with the tenant and correlation ID attached, so
+// log lines can be filtered by tenant and one audit traced across goroutines
+func (t Tenant) Logger() *slog.Logger {
+	logger := slog.Default()
+	if t.ProjectID != "" {
+		logger = logger.With("project_id", t.ProjectID)
+	}
+	if t.ClientID != "" {
+		logger = logger.With("client_id", t.ClientID)
+	}
+	if t.CorrelationID != "" {
+		logger = logger.With("correlation_id", t.CorrelationID)
+	}
+	return logger
+}
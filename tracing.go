@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/chromedp/chromedp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of scrapes and audits. It is a no-op until setupTracing
+// installs an exporting provider.
+var tracer = otel.Tracer("go-scraper")
+
+// setupTracing exports spans over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set, the exporter reads the other standard
+// OTEL_* variables (headers, sampler, OTEL_SERVICE_NAME). Incoming W3C traceparent and
+// baggage headers are always honoured. The returned func flushes pending spans.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Attributes from the environment override the default service name
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "go-scraper")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// withSpan returns ctx carrying the span of traceCtx. Tabs are created from the
+// browser's context, this keeps their spans in the trace of the request or audit.
+func withSpan(ctx context.Context, traceCtx context.Context) context.Context {
+	return trace.ContextWithSpan(ctx, trace.SpanFromContext(traceCtx))
+}
+
+// endSpan ends a span, marking it failed when err is set
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// pageSpans splits a page load into child spans of the page's span, one per phase
+type pageSpans struct {
+	ctx     context.Context
+	current trace.Span
+}
+
+// phase returns an action that ends the running phase and starts the span of name
+func (s *pageSpans) phase(name string) chromedp.Action {
+	return chromedp.ActionFunc(func(context.Context) error {
+		s.end(nil)
+		_, s.current = tracer.Start(s.ctx, name)
+		return nil
+	})
+}
+
+// end ends the running phase, the error is recorded on it
+func (s *pageSpans) end(err error) {
+	if s.current != nil {
+		endSpan(s.current, err)
+		s.current = nil
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ttlCacheEntry is a cached value and when it was stored
+type ttlCacheEntry[V any] struct {
+	value    V
+	storedAt time.Time
+}
+
+// ttlCache remembers values per key for ttl, for verdicts that are shared between
+// audits but can change on the server, e.g. whether links are alive (LinkCache).
+// Entries older than the TTL count as not cached so they get checked again.
+type ttlCache[V any] struct {
+	entries   map[string]ttlCacheEntry[V]
+	ttl       time.Duration
+	lastPrune time.Time
+	mu        sync.RWMutex
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		entries:   make(map[string]ttlCacheEntry[V]),
+		ttl:       ttl,
+		lastPrune: time.Now(),
+	}
+}
+
+// Get returns the value stored for key and whether it's there and younger than the TTL
+func (c *ttlCache[V]) Get(key string) (value V, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.storedAt) > c.ttl {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set stores value for key
+func (c *ttlCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.entries[key] = ttlCacheEntry[V]{value: value, storedAt: now}
+
+	// Drop expired entries once per TTL so a long-running server doesn't keep every key forever
+	if now.Sub(c.lastPrune) > c.ttl {
+		for cachedKey, entry := range c.entries {
+			if now.Sub(entry.storedAt) > c.ttl {
+				delete(c.entries, cachedKey)
+			}
+		}
+		c.lastPrune = now
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCacheExpires(t *testing.T) {
+	cache := newTTLCache[string](50 * time.Millisecond)
+
+	cache.Set("example.com:443", "")
+	if _, ok := cache.Get("example.com:443"); !ok {
+		t.Fatal("fresh entry not found")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := cache.Get("example.com:443"); ok {
+		t.Error("entry older than the TTL still returned")
+	}
+
+	// The next Set prunes the expired entry
+	cache.Set("example.org:443", "certificate expired on 2026-01-01")
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	if _, ok := cache.entries["example.com:443"]; ok || len(cache.entries) != 1 {
+		t.Errorf("got entries %v, want only example.org:443", cache.entries)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// Wait strategies of the wait_for option, "selector" and "delay" take an argument
+// after a colon, e.g. "selector:#app .product" or "delay:1500"
+const (
+	WaitForBody        = "body"
+	WaitForSelector    = "selector"
+	WaitForNetworkIdle = "networkidle"
+	WaitForDelay       = "delay"
+)
+
+// MaxWaitForDelay bounds "delay:<ms>", it has to fit in the page timeout
+const MaxWaitForDelay = 20 * time.Second
+
+// networkIdleTimeout is how long "networkidle" waits before reading the page anyway,
+// pages that poll or stream never go idle
+const networkIdleTimeout = 10 * time.Second
+
+// validateWaitFor checks the wait_for request field, empty keeps the default wait
+func validateWaitFor(waitFor string) error {
+	if waitFor == "" {
+		return nil
+	}
+	mode, arg, hasArg := strings.Cut(waitFor, ":")
+	switch mode {
+	case WaitForBody, WaitForNetworkIdle:
+		if hasArg {
+			return fmt.Errorf("wait_for %q takes no argument", mode)
+		}
+	case WaitForSelector:
+		if strings.TrimSpace(arg) == "" {
+			return errors.New("wait_for selector:<css> needs a selector")
+		}
+	case WaitForDelay:
+		ms, err := strconv.Atoi(arg)
+		if err != nil || ms <= 0 || time.Duration(ms)*time.Millisecond > MaxWaitForDelay {
+			return fmt.Errorf("wait_for delay:<ms> needs a delay between 1 and %d ms", MaxWaitForDelay.Milliseconds())
+		}
+	default:
+		return fmt.Errorf("wait_for must be %s, %s:<css>, %s or %s:<ms>", WaitForBody, WaitForSelector, WaitForNetworkIdle, WaitForDelay)
+	}
+	return nil
+}
+
+// pageWait returns the actions of a wait_for option that passed validateWaitFor: setup
+// runs before navigating and wait after it. Must be called before chromedp.Run.
+func pageWait(taskCtx context.Context, waitFor string) (setup chromedp.Action, wait chromedp.Action) {
+	mode, arg, _ := strings.Cut(waitFor, ":")
+	switch mode {
+	case WaitForSelector:
+		return chromedp.Tasks{}, chromedp.WaitVisible(arg, chromedp.ByQuery)
+	case WaitForNetworkIdle:
+		idle := listenNetworkIdle(taskCtx)
+		return page.SetLifecycleEventsEnabled(true), waitNetworkIdle(idle)
+	case WaitForDelay:
+		ms, _ := strconv.Atoi(arg)
+		return chromedp.Tasks{}, chromedp.Sleep(time.Duration(ms) * time.Millisecond)
+	default:
+		return chromedp.Tasks{}, chromedp.WaitVisible("body", chromedp.ByQuery)
+	}
+}
+
+// listenNetworkIdle signals once Chrome reports the main frame's network idle, no
+// connections for 500ms. A new document in the main frame, e.g. after a client-side
+// redirect, resets it.
+func listenNetworkIdle(taskCtx context.Context) <-chan struct{} {
+	idle := make(chan struct{}, 1)
+	chromedp.ListenTarget(taskCtx, func(ev interface{}) {
+		e, ok := ev.(*page.EventLifecycleEvent)
+		if !ok {
+			return
+		}
+		// The main frame has the ID of its target
+		if c := chromedp.FromContext(taskCtx); c == nil || c.Target == nil || string(e.FrameID) != string(c.Target.TargetID) {
+			return
+		}
+		switch e.Name {
+		case "init":
+			select {
+			case <-idle:
+			default:
+			}
+		case "networkIdle":
+			select {
+			case idle <- struct{}{}:
+			default:
+			}
+		}
+	})
+	return idle
+}
+
+// waitNetworkIdle waits for listenNetworkIdle for up to networkIdleTimeout
+func waitNetworkIdle(idle <-chan struct{}) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		timer := time.NewTimer(networkIdleTimeout)
+		defer timer.Stop()
+		select {
+		case <-idle:
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+}
@@ -1,21 +1,43 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"sync"
 	"time"
 )
 
 // WorkerPool represents a pool of workers that process tasks concurrently
 type WorkerPool[T any] struct {
+	ctx          context.Context // cancelling it aborts running tasks and skips queued ones
 	maxWorkers   int
 	taskQueue    chan string
 	resultQueue  chan TaskResult[T]
 	results      []TaskResult[T]
 	resultsMux   sync.RWMutex
+	cursor       int             // results already returned by GetNewResults, guarded by resultsMux
 	processed    map[string]bool // Track processed items
 	processedMux sync.RWMutex    // Mutex for processed map
+	dedupKey     func(string) string
+	rampUp       time.Duration
+	logger       *slog.Logger
 	wg           sync.WaitGroup
+	paused       bool
+	pauseCond    *sync.Cond    // guards paused, signalled on resume
+	notify       chan struct{} // receives a value after new results are collected, closed once the pool stopped
+	stopOnce     sync.Once
+	stopped      bool         // set by Stop before closing taskQueue, AddTask adds nothing after
+	stopMux      sync.RWMutex // held for reading while AddTask sends to taskQueue
+
+	// With spill enabled, tasks that don't fit in taskQueue wait in frontier and a
+	// feeder goroutine moves them over as workers free up space
+	spill        bool
+	frontier     []string
+	frontierPeak int
+	frontierMux  sync.Mutex
+	frontierWake chan struct{} // receives a value when tasks were added to the frontier
+	frontierStop chan struct{} // closed by Stop, the feeder empties the frontier and exits and ramping workers don't start
+	feederDone   chan struct{}
 }
 
 // TaskResult represents the result of processing a task
@@ -26,48 +48,146 @@ type TaskResult[T any] struct {
 }
 
 // TaskFunction defines the signature for functions that process tasks
-// Returns a result (any type) and an error
-type TaskFunction[T any] func(string) (T, error)
+// Returns a result (any type) and an error, ctx is the pool's context
+type TaskFunction[T any] func(ctx context.Context, data string) (T, error)
 
 // NewWorkerPool creates a new worker pool with the specified number of workers
-func NewWorkerPool[T any](maxWorkers int) *WorkerPool[T] {
+func NewWorkerPool[T any](ctx context.Context, maxWorkers int) *WorkerPool[T] {
 	return &WorkerPool[T]{
+		ctx:         ctx,
 		maxWorkers:  maxWorkers,
 		taskQueue:   make(chan string, maxWorkers*2), // Buffer to prevent blocking
 		resultQueue: make(chan TaskResult[T], maxWorkers*2),
 		results:     make([]TaskResult[T], 0),
 		processed:   make(map[string]bool),
+		dedupKey:    func(data string) string { return data },
+		logger:      slog.Default(),
+		pauseCond:   sync.NewCond(&sync.Mutex{}),
+		notify:      make(chan struct{}, 1),
+
+		frontierWake: make(chan struct{}, 1),
+		frontierStop: make(chan struct{}),
+		feederDone:   make(chan struct{}),
 	}
 }
 
+// DedupBy sets the function mapping a task to the key used to detect duplicates.
+// Must be called before adding tasks.
+func (wp *WorkerPool[T]) DedupBy(keyFunc func(string) string) {
+	wp.dedupKey = keyFunc
+}
+
+// LogTo sets the logger for task errors, e.g. one carrying the audit's correlation ID.
+// Must be called before Start.
+func (wp *WorkerPool[T]) LogTo(logger *slog.Logger) {
+	wp.logger = logger
+}
+
+// RampUp spreads worker startup over d instead of starting every worker at once.
+// Must be called before Start.
+func (wp *WorkerPool[T]) RampUp(d time.Duration) {
+	wp.rampUp = d
+}
+
+// QueueSize sets the task queue buffer, maxWorkers*2 by default. Once it is full AddTask
+// blocks, unless the pool spills to the frontier. Must be called before adding tasks.
+func (wp *WorkerPool[T]) QueueSize(size int) {
+	wp.taskQueue = make(chan string, size)
+}
+
+// SpillToFrontier makes AddTask put tasks that don't fit in the queue into an unbounded
+// frontier instead of blocking, so discovering links never waits for pages to be audited.
+// Must be called before Start.
+func (wp *WorkerPool[T]) SpillToFrontier() {
+	wp.spill = true
+}
+
 // Start initializes and starts the worker pool
 func (wp *WorkerPool[T]) Start(taskFunc TaskFunction[T]) {
 	// Start result collector goroutine
 	go wp.resultCollector()
 
-	// Start the specified number of workers
+	if wp.spill {
+		go wp.feeder()
+	} else {
+		close(wp.feederDone)
+	}
+
+	// Start the specified number of workers, the first one right away and
+	// the rest evenly spread over the ramp-up
+	var step time.Duration
+	if wp.maxWorkers > 1 {
+		step = wp.rampUp / time.Duration(wp.maxWorkers-1)
+	}
 	for i := 0; i < wp.maxWorkers; i++ {
 		wp.wg.Add(1)
-		go wp.worker(i, taskFunc)
+		if i == 0 || step == 0 {
+			go wp.worker(i, taskFunc)
+			continue
+		}
+		go wp.delayedWorker(i, time.Duration(i)*step, taskFunc)
+	}
+}
+
+// delayedWorker starts a worker after delay. Workers that haven't started when the pool
+// stops or its context is cancelled never do, the first worker drains the queue.
+func (wp *WorkerPool[T]) delayedWorker(workerID int, delay time.Duration, taskFunc TaskFunction[T]) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		wp.worker(workerID, taskFunc)
+	case <-wp.ctx.Done():
+		wp.wg.Done()
+	case <-wp.frontierStop:
+		wp.wg.Done()
 	}
 }
 
 // resultCollector collects results from workers
 func (wp *WorkerPool[T]) resultCollector() {
+	defer close(wp.notify)
+
 	for result := range wp.resultQueue {
 		wp.resultsMux.Lock()
 		wp.results = append(wp.results, result)
 		wp.resultsMux.Unlock()
+
+		// Wake up a waiting reader, a pending signal already covers this result
+		select {
+		case wp.notify <- struct{}{}:
+		default:
+		}
 	}
 }
 
+// ResultReady receives a value when results were collected since the last receive.
+// It is closed once the pool has stopped and every result is collected.
+func (wp *WorkerPool[T]) ResultReady() <-chan struct{} {
+	return wp.notify
+}
+
+// Idle reports whether every task added so far has a result
+func (wp *WorkerPool[T]) Idle() bool {
+	return wp.ResultCount() >= wp.QueuedCount()
+}
+
 // worker is the goroutine that processes tasks from the queue
 func (wp *WorkerPool[T]) worker(workerID int, taskFunc TaskFunction[T]) {
 	defer wp.wg.Done()
 
 	for data := range wp.taskQueue {
+		// Hold on to the task while paused, it runs once the pool resumes
+		wp.waitWhilePaused()
+
+		// Keep draining the queue after cancellation so AddTask never blocks, but run nothing
+		if wp.ctx.Err() != nil {
+			continue
+		}
+
 		// Execute the task function
-		result, err := taskFunc(data)
+		result, err := taskFunc(wp.ctx, data)
 
 		// Create task result
 		taskResult := TaskResult[T]{
@@ -80,7 +200,7 @@ func (wp *WorkerPool[T]) worker(workerID int, taskFunc TaskFunction[T]) {
 		wp.resultQueue <- taskResult
 
 		if err != nil {
-			fmt.Printf("Worker %d: Error processing %s: %v\n", workerID, data, err)
+			wp.logger.Error("task failed", "worker_id", workerID, "url", data, "error", err)
 		}
 	}
 }
@@ -88,20 +208,97 @@ func (wp *WorkerPool[T]) worker(workerID int, taskFunc TaskFunction[T]) {
 // AddTask adds a new task to the queue if it hasn't been processed yet
 // Returns true if the task was added, false if it was already processed/queued
 func (wp *WorkerPool[T]) AddTask(data string) bool {
-	wp.processedMux.Lock()
-	defer wp.processedMux.Unlock()
+	// The pool can be stopped from another goroutine (cancel), keep Stop from closing
+	// the queue while this task is being sent
+	wp.stopMux.RLock()
+	defer wp.stopMux.RUnlock()
+	if wp.stopped {
+		return false
+	}
 
-	// Check if already processed or queued
-	if wp.processed[data] {
+	key := wp.dedupKey(data)
+
+	// Check and mark as processed (queued) under the lock
+	wp.processedMux.Lock()
+	if wp.processed[key] {
+		wp.processedMux.Unlock()
 		return false
 	}
+	wp.processed[key] = true
+	wp.processedMux.Unlock()
 
-	// Mark as processed (queued) and add to queue
-	wp.processed[data] = true
+	if wp.spill {
+		wp.enqueueOrSpill(data)
+		return true
+	}
+
+	// Send after unlocking, the send blocks while the queue is full and
+	// other callers must still be able to add or look up tasks meanwhile
 	wp.taskQueue <- data
 	return true
 }
 
+// enqueueOrSpill queues data if there is room and nothing is waiting in the frontier,
+// otherwise appends it to the frontier so tasks keep their order
+func (wp *WorkerPool[T]) enqueueOrSpill(data string) {
+	wp.frontierMux.Lock()
+	defer wp.frontierMux.Unlock()
+
+	if len(wp.frontier) == 0 {
+		select {
+		case wp.taskQueue <- data:
+			return
+		default:
+		}
+	}
+
+	wp.frontier = append(wp.frontier, data)
+	wp.frontierPeak = max(wp.frontierPeak, len(wp.frontier))
+
+	select {
+	case wp.frontierWake <- struct{}{}:
+	default:
+	}
+}
+
+// feeder moves frontier tasks into the queue as space frees up. After Stop it
+// queues what is left in the frontier, like tasks already queued, then exits.
+func (wp *WorkerPool[T]) feeder() {
+	defer close(wp.feederDone)
+
+	for {
+		wp.frontierMux.Lock()
+		if len(wp.frontier) == 0 {
+			wp.frontierMux.Unlock()
+			select {
+			case <-wp.frontierWake:
+				continue
+			case <-wp.frontierStop:
+				return
+			}
+		}
+		data := wp.frontier[0]
+		wp.frontier = wp.frontier[1:]
+		wp.frontierMux.Unlock()
+
+		wp.taskQueue <- data
+	}
+}
+
+// FrontierSize returns the number of tasks waiting for room in the queue
+func (wp *WorkerPool[T]) FrontierSize() int {
+	wp.frontierMux.Lock()
+	defer wp.frontierMux.Unlock()
+	return len(wp.frontier)
+}
+
+// FrontierPeak returns the largest the frontier has been
+func (wp *WorkerPool[T]) FrontierPeak() int {
+	wp.frontierMux.Lock()
+	defer wp.frontierMux.Unlock()
+	return wp.frontierPeak
+}
+
 // AddTasks adds multiple tasks from a string array, skipping duplicates
 // Returns the number of tasks actually added
 func (wp *WorkerPool[T]) AddTasks(items []string) int {
@@ -118,16 +315,67 @@ func (wp *WorkerPool[T]) AddTasks(items []string) int {
 func (wp *WorkerPool[T]) HasBeenProcessed(data string) bool {
 	wp.processedMux.RLock()
 	defer wp.processedMux.RUnlock()
-	return wp.processed[data]
+	return wp.processed[wp.dedupKey(data)]
+}
+
+// QueuedCount returns the number of distinct tasks added so far
+func (wp *WorkerPool[T]) QueuedCount() int {
+	wp.processedMux.RLock()
+	defer wp.processedMux.RUnlock()
+	return len(wp.processed)
+}
+
+// Pause stops workers from starting new tasks. Running tasks finish and
+// queued tasks stay queued until Resume.
+func (wp *WorkerPool[T]) Pause() {
+	wp.pauseCond.L.Lock()
+	defer wp.pauseCond.L.Unlock()
+	wp.paused = true
+}
+
+// Resume lets paused workers continue with the queued tasks
+func (wp *WorkerPool[T]) Resume() {
+	wp.pauseCond.L.Lock()
+	defer wp.pauseCond.L.Unlock()
+	wp.paused = false
+	wp.pauseCond.Broadcast()
+}
+
+// IsPaused reports whether the pool is paused
+func (wp *WorkerPool[T]) IsPaused() bool {
+	wp.pauseCond.L.Lock()
+	defer wp.pauseCond.L.Unlock()
+	return wp.paused
+}
+
+// waitWhilePaused blocks the calling worker until the pool isn't paused
+func (wp *WorkerPool[T]) waitWhilePaused() {
+	wp.pauseCond.L.Lock()
+	defer wp.pauseCond.L.Unlock()
+	for wp.paused {
+		wp.pauseCond.Wait()
+	}
 }
 
-// Stop closes the task queue and waits for all workers to finish
+// Stop closes the task queue and waits for all workers to finish and their
+// results to be collected. Calling it again is a no-op.
 func (wp *WorkerPool[T]) Stop() {
-	close(wp.taskQueue)
-	wp.wait()
-	close(wp.resultQueue)
-	// Give result collector time to finish
-	time.Sleep(time.Millisecond * 10)
+	wp.stopOnce.Do(func() {
+		// Paused workers would never drain the queue
+		wp.Resume()
+		close(wp.frontierStop)
+		<-wp.feederDone
+		wp.stopMux.Lock()
+		wp.stopped = true
+		close(wp.taskQueue)
+		wp.stopMux.Unlock()
+		wp.wait()
+		close(wp.resultQueue)
+	})
+
+	// The collector closes notify once the last result is stored
+	for range wp.notify {
+	}
 }
 
 // GetResults returns a copy of all collected results
@@ -141,6 +389,24 @@ func (wp *WorkerPool[T]) GetResults() []TaskResult[T] {
 	return resultsCopy
 }
 
+// GetNewResults returns the results collected since the previous call
+func (wp *WorkerPool[T]) GetNewResults() []TaskResult[T] {
+	wp.resultsMux.Lock()
+	defer wp.resultsMux.Unlock()
+
+	newResults := make([]TaskResult[T], len(wp.results)-wp.cursor)
+	copy(newResults, wp.results[wp.cursor:])
+	wp.cursor = len(wp.results)
+	return newResults
+}
+
+// ResultCount returns the number of results collected so far
+func (wp *WorkerPool[T]) ResultCount() int {
+	wp.resultsMux.RLock()
+	defer wp.resultsMux.RUnlock()
+	return len(wp.results)
+}
+
 // GetResultsMap returns results organized by data string for easy lookup
 func (wp *WorkerPool[T]) GetResultsMap() map[string]TaskResult[T] {
 	wp.resultsMux.RLock()
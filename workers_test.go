@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestAddTaskFloodDoesNotDeadlock adds overlapping tasks from many goroutines to a pool
+// whose queue holds a single task, so most sends block while others look tasks up
+func TestAddTaskFloodDoesNotDeadlock(t *testing.T) {
+	pool := NewWorkerPool[string](context.Background(), 2)
+	pool.QueueSize(1)
+	pool.Start(func(ctx context.Context, data string) (string, error) {
+		time.Sleep(time.Millisecond)
+		return data, nil
+	})
+
+	const adders, tasks = 20, 50
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for i := range adders {
+			wg.Go(func() {
+				for j := range tasks {
+					// Every other adder adds the same tasks, half of them are duplicates
+					task := fmt.Sprintf("https://example.com/%d/%d", i/2, j)
+					pool.AddTask(task)
+					pool.HasBeenProcessed(task)
+				}
+			})
+		}
+		wg.Wait()
+		pool.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("AddTask deadlocked")
+	}
+
+	want := adders / 2 * tasks
+	if got := len(pool.GetResults()); got != want {
+		t.Errorf("got %d results, want %d", got, want)
+	}
+}
+
+// TestStopDuringRampUp stops a pool whose workers are still waiting to start, the
+// first worker audits the one page and Stop doesn't wait out the ramp-up
+func TestStopDuringRampUp(t *testing.T) {
+	pool := NewWorkerPool[string](context.Background(), 4)
+	pool.RampUp(time.Hour)
+	pool.Start(func(ctx context.Context, data string) (string, error) {
+		return data, nil
+	})
+	pool.AddTask("https://example.com/")
+
+	done := make(chan struct{})
+	go func() {
+		pool.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop waited for the ramp-up")
+	}
+	if got := len(pool.GetResults()); got != 1 {
+		t.Errorf("got %d results, want 1", got)
+	}
+}